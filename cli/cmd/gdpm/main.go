@@ -6,6 +6,9 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/aviorstudio/gdpm/cli/internal/commands"
@@ -16,6 +19,24 @@ func main() {
 }
 
 func run(args []string) int {
+	// The first SIGINT/SIGTERM cancels the command so it can roll back and
+	// release the project lock; a second one kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	code := runCommand(ctx, args)
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "interrupted")
+		return 130
+	}
+	return code
+}
+
+func runCommand(ctx context.Context, args []string) int {
 	if len(args) < 2 {
 		printUsage()
 		return 2
@@ -27,17 +48,35 @@ func run(args []string) int {
 		printUsage()
 		return 0
 	case "init":
-		return runInit(args[2:])
+		return runInit(ctx, args[2:])
 	case "add":
-		return runAdd(args[2:])
+		return runAdd(ctx, args[2:])
 	case "remove", "rm":
-		return runRemove(args[2:])
+		return runRemove(ctx, args[2:])
 	case "link":
-		return runLink(args[2:])
+		return runLink(ctx, args[2:])
 	case "unlink":
-		return runUnlink(args[2:])
+		return runUnlink(ctx, args[2:])
 	case "install":
-		return runInstall(args[2:])
+		return runInstall(ctx, args[2:])
+	case "outdated":
+		return runOutdated(ctx, args[2:])
+	case "update":
+		return runUpdate(ctx, args[2:])
+	case "tree":
+		return runTree(ctx, args[2:])
+	case "why":
+		return runWhy(ctx, args[2:])
+	case "cache":
+		return runCache(ctx, args[2:])
+	case "search":
+		return runSearch(ctx, args[2:])
+	case "info":
+		return runInfo(ctx, args[2:])
+	case "prune":
+		return runPrune(ctx, args[2:])
+	case "doctor", "verify":
+		return runDoctor(ctx, args[2:])
 	default:
 		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", cmd)
 		printUsage()
@@ -45,7 +84,7 @@ func run(args []string) int {
 	}
 }
 
-func runInit(args []string) int {
+func runInit(ctx context.Context, args []string) int {
 	fs := flag.NewFlagSet("init", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 	if err := fs.Parse(args); err != nil {
@@ -56,7 +95,7 @@ func runInit(args []string) int {
 		return 2
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	if err := commands.Init(ctx, commands.InitOptions{}); err != nil {
@@ -66,22 +105,29 @@ func runInit(args []string) int {
 	return 0
 }
 
-func runAdd(args []string) int {
+func runAdd(ctx context.Context, args []string) int {
 	fs := flag.NewFlagSet("add", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
+	pre := fs.Bool("pre", false, "consider pre-release versions")
+	as := fs.String("as", "", "plugin name to use when adding from a repository URL or local path (@owner/name)")
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
 	if fs.NArg() != 1 {
-		fmt.Fprintln(os.Stderr, "usage: gdpm add @username/plugin[@version]")
+		fmt.Fprintln(os.Stderr, "usage: gdpm add [--pre] @username/plugin[@version|@range]")
+		fmt.Fprintln(os.Stderr, "       gdpm add [--as @owner/name] github.com/owner/repo[/tree/ref/sub/dir][@ref]")
+		fmt.Fprintln(os.Stderr, "       gdpm add [--as @owner/name] git+<remote>[#ref][:sub/dir]")
+		fmt.Fprintln(os.Stderr, "       gdpm add --as @owner/name ./path/to/addon")
 		return 2
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
 	if err := commands.Add(ctx, commands.AddOptions{
-		Spec: fs.Arg(0),
+		Spec:       fs.Arg(0),
+		As:         *as,
+		Prerelease: *pre,
 	}); err != nil {
 		if errors.Is(err, commands.ErrUserInput) {
 			fmt.Fprintln(os.Stderr, err)
@@ -93,7 +139,7 @@ func runAdd(args []string) int {
 	return 0
 }
 
-func runRemove(args []string) int {
+func runRemove(ctx context.Context, args []string) int {
 	fs := flag.NewFlagSet("remove", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 	if err := fs.Parse(args); err != nil {
@@ -104,7 +150,7 @@ func runRemove(args []string) int {
 		return 2
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	if err := commands.Remove(ctx, commands.RemoveOptions{
@@ -120,7 +166,7 @@ func runRemove(args []string) int {
 	return 0
 }
 
-func runLink(args []string) int {
+func runLink(ctx context.Context, args []string) int {
 	fs := flag.NewFlagSet("link", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 	if err := fs.Parse(args); err != nil {
@@ -132,7 +178,7 @@ func runLink(args []string) int {
 		return 2
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	var localPath string
@@ -155,33 +201,37 @@ func runLink(args []string) int {
 	return 0
 }
 
-func runUnlink(args []string) int {
+func runUnlink(ctx context.Context, args []string) int {
 	fs := flag.NewFlagSet("unlink", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
 	all := fs.Bool("all", false, "unlink all linked plugins")
+	offline := fs.Bool("offline", false, "only use the download cache (also GDPM_OFFLINE=1)")
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
 	if *all {
 		if fs.NArg() != 0 {
-			fmt.Fprintln(os.Stderr, "usage: gdpm unlink --all")
+			fmt.Fprintln(os.Stderr, "usage: gdpm unlink [--offline] --all")
 			return 2
 		}
 	} else if fs.NArg() != 1 {
-		fmt.Fprintln(os.Stderr, "usage: gdpm unlink @username/plugin")
-		fmt.Fprintln(os.Stderr, "       gdpm unlink --all")
+		fmt.Fprintln(os.Stderr, "usage: gdpm unlink [--offline] @username/plugin")
+		fmt.Fprintln(os.Stderr, "       gdpm unlink [--offline] --all")
 		return 2
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
 	var err error
 	if *all {
-		err = commands.UnlinkAll(ctx, commands.UnlinkAllOptions{})
+		err = commands.UnlinkAll(ctx, commands.UnlinkAllOptions{
+			Offline: *offline,
+		})
 	} else {
 		err = commands.Unlink(ctx, commands.UnlinkOptions{
-			Spec: fs.Arg(0),
+			Spec:    fs.Arg(0),
+			Offline: *offline,
 		})
 	}
 	if err != nil {
@@ -195,21 +245,294 @@ func runUnlink(args []string) int {
 	return 0
 }
 
-func runInstall(args []string) int {
+func runInstall(ctx context.Context, args []string) int {
 	fs := flag.NewFlagSet("install", flag.ContinueOnError)
 	fs.SetOutput(os.Stderr)
+	frozen := fs.Bool("frozen", false, "fail if gdpm.lock is missing, out of date, or a hash does not match")
+	offline := fs.Bool("offline", false, "never use the network; only install from the download cache (also GDPM_OFFLINE=1)")
+	repair := fs.Bool("repair", false, "reinstall addons whose files differ from gdpm.lock")
+	force := fs.Bool("force", false, "reinstall every addon, even if it matches gdpm.lock")
+	prune := fs.Bool("prune", false, "remove addons/@* directories that no plugin in gdpm.json owns or depends on")
+	yes := fs.Bool("yes", false, "with --prune, remove without asking for confirmation")
+	jobs := fs.Int("jobs", 4, "number of plugins to download in parallel")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 || *jobs < 1 {
+		fmt.Fprintln(os.Stderr, "usage: gdpm install [--frozen] [--offline] [--repair] [--force] [--prune [--yes]] [--jobs N]")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if err := commands.Install(ctx, commands.InstallOptions{
+		Frozen:  *frozen,
+		Offline: *offline,
+		Repair:  *repair,
+		Force:   *force,
+		Prune:   *prune,
+		Yes:     *yes,
+		Jobs:    *jobs,
+	}); err != nil {
+		if errors.Is(err, commands.ErrUserInput) {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func runUpdate(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	pre := fs.Bool("pre", false, "consider pre-release versions")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if err := commands.Update(ctx, commands.UpdateOptions{
+		Specs:      fs.Args(),
+		Prerelease: *pre,
+	}); err != nil {
+		if errors.Is(err, commands.ErrUserInput) {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func runOutdated(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("outdated", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: gdpm outdated")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	if err := commands.Outdated(ctx, commands.OutdatedOptions{}); err != nil {
+		if errors.Is(err, commands.ErrOutdated) {
+			return 1
+		}
+		if errors.Is(err, commands.ErrUserInput) {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func runPrune(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	dryRun := fs.Bool("dry-run", false, "list what would be removed without removing it")
+	yes := fs.Bool("yes", false, "remove without asking for confirmation")
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
 	if fs.NArg() != 0 {
-		fmt.Fprintln(os.Stderr, "usage: gdpm install")
+		fmt.Fprintln(os.Stderr, "usage: gdpm prune [--dry-run] [--yes]")
 		return 2
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	if err := commands.Install(ctx, commands.InstallOptions{}); err != nil {
+	if err := commands.Prune(ctx, commands.PruneOptions{DryRun: *dryRun, Yes: *yes}); err != nil {
+		if errors.Is(err, commands.ErrUserInput) {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func runDoctor(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fix := fs.Bool("fix", false, "repair the problems that are safe to fix automatically")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: gdpm doctor [--fix]")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	if err := commands.Doctor(ctx, commands.DoctorOptions{Fix: *fix}); err != nil {
+		if errors.Is(err, commands.ErrUnhealthy) {
+			return 1
+		}
+		if errors.Is(err, commands.ErrUserInput) {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func runTree(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("tree", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: gdpm tree")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if err := commands.Tree(ctx, commands.TreeOptions{}); err != nil {
+		if errors.Is(err, commands.ErrUserInput) {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func runWhy(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("why", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: gdpm why @username/plugin")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if err := commands.Why(ctx, commands.WhyOptions{
+		Spec: fs.Arg(0),
+	}); err != nil {
+		if errors.Is(err, commands.ErrUserInput) {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func runSearch(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	limit := fs.Int("limit", 20, "number of results per page")
+	page := fs.Int("page", 1, "page of results to show")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 || *limit < 1 || *page < 1 {
+		fmt.Fprintln(os.Stderr, "usage: gdpm search [--json] [--limit N] [--page N] <text>")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if err := commands.Search(ctx, commands.SearchOptions{
+		Query: strings.Join(fs.Args(), " "),
+		Limit: *limit,
+		Page:  *page,
+		JSON:  *asJSON,
+	}); err != nil {
+		if errors.Is(err, commands.ErrUserInput) {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func runInfo(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("info", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: gdpm info @username/plugin")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if err := commands.Info(ctx, commands.InfoOptions{
+		Spec: fs.Arg(0),
+	}); err != nil {
+		if errors.Is(err, commands.ErrUserInput) {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func runCache(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("cache", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: gdpm cache ls|clean|verify")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	var err error
+	switch fs.Arg(0) {
+	case "ls", "list":
+		err = commands.CacheList(ctx, commands.CacheListOptions{})
+	case "clean":
+		err = commands.CacheClean(ctx, commands.CacheCleanOptions{})
+	case "verify":
+		err = commands.CacheVerify(ctx, commands.CacheVerifyOptions{})
+	default:
+		fmt.Fprintln(os.Stderr, "usage: gdpm cache ls|clean|verify")
+		return 2
+	}
+	if err != nil {
 		if errors.Is(err, commands.ErrUserInput) {
 			fmt.Fprintln(os.Stderr, err)
 			return 2
@@ -225,13 +548,36 @@ func printUsage() {
 
 Usage:
   gdpm init
-  gdpm add @username/plugin[@version]
-  gdpm install
+  gdpm add [--pre] @username/plugin[@version|@range]
+  gdpm add [--as @owner/name] github.com/owner/repo[/tree/ref/sub/dir][@ref]
+  gdpm add [--as @owner/name] git+<remote>[#ref][:sub/dir]
+  gdpm add --as @owner/name ./path/to/addon
+  gdpm install [--frozen] [--offline] [--repair] [--force] [--prune [--yes]] [--jobs N]
+  gdpm update [--pre] [@username/plugin...]
+  gdpm outdated
+  gdpm search [--json] [--limit N] [--page N] <text>
+  gdpm info @username/plugin
+  gdpm tree
+  gdpm why @username/plugin
+  gdpm doctor [--fix]
+  gdpm cache ls|clean|verify
   gdpm remove @username/plugin
+  gdpm prune [--dry-run] [--yes]
   gdpm link @username/plugin [local_path]
-  gdpm unlink @username/plugin
-  gdpm unlink --all
+  gdpm unlink [--offline] @username/plugin
+  gdpm unlink [--offline] --all
 
 Environment:
-  GITHUB_TOKEN   Optional GitHub token to avoid rate limits.`)
+  GITHUB_TOKEN          Optional GitHub token to avoid rate limits.
+  GDPM_GITHUB_HOSTS     GitHub Enterprise hosts, comma-separated (host or host=api_url).
+  GITHUB_ENTERPRISE_TOKEN
+                        Token for GitHub Enterprise hosts without their own token.
+  GITLAB_TOKEN          Optional token for private projects on gitlab.com.
+  GITEA_TOKEN           Optional token for private repositories on codeberg.org and gitea.com.
+  GDPM_REGISTRY         Registry to resolve plugins from: a PostgREST URL, or a JSON index file/URL.
+  GDPM_REGISTRY_KEY     API key for a PostgREST registry.
+  GDPM_CONFIG           Config file with named registries, GitHub hosts and forge hosts (default: user config dir + /gdpm/config.json).
+  GDPM_CACHE_DIR        Download cache directory (default: user cache dir + /gdpm).
+  GDPM_CACHE_MAX_SIZE   Download cache size limit, e.g. 512MB (default: 1GB, 0 for unlimited).
+  GDPM_OFFLINE          Set to 1 to run install and unlink without network access.`)
 }
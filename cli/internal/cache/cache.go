@@ -0,0 +1,380 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
+)
+
+const (
+	DirEnv     = "GDPM_CACHE_DIR"
+	MaxSizeEnv = "GDPM_CACHE_MAX_SIZE"
+
+	DefaultMaxSize int64 = 1 << 30
+
+	zipballsDir  = "zipballs"
+	zipballExt   = ".zip"
+	digestSuffix = ".sha256"
+)
+
+type Cache struct {
+	dir     string
+	maxSize int64
+
+	evictMu sync.Mutex
+}
+
+type Entry struct {
+	Owner    string
+	Repo     string
+	SHA      string
+	Path     string
+	Size     int64
+	LastUsed time.Time
+}
+
+func (e Entry) Key() string {
+	return e.Owner + "/" + e.Repo + "@" + e.SHA
+}
+
+func New(dir string, maxSize int64) *Cache {
+	return &Cache{dir: dir, maxSize: maxSize}
+}
+
+func Open() (*Cache, error) {
+	dir, err := DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	maxSize := DefaultMaxSize
+	if v := strings.TrimSpace(os.Getenv(MaxSizeEnv)); v != "" {
+		maxSize, err = ParseSize(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", MaxSizeEnv, err)
+		}
+	}
+	return New(dir, maxSize), nil
+}
+
+func DefaultDir() (string, error) {
+	if v := strings.TrimSpace(os.Getenv(DirEnv)); v != "" {
+		dir, err := fsutil.ExpandHome(v)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Abs(dir)
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "gdpm"), nil
+}
+
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func Cacheable(sha string) bool {
+	if len(sha) < 40 || len(sha) > 64 {
+		return false
+	}
+	for _, r := range sha {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Cache) zipballPath(owner, repo, sha string) (string, error) {
+	for _, part := range []string{owner, repo} {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `/\`) {
+			return "", fmt.Errorf("invalid cache key %s/%s@%s", owner, repo, sha)
+		}
+	}
+	if !Cacheable(sha) {
+		return "", fmt.Errorf("invalid cache key %s/%s@%s", owner, repo, sha)
+	}
+	return filepath.Join(c.dir, zipballsDir, owner, repo, sha+zipballExt), nil
+}
+
+func (c *Cache) HasZipball(owner, repo, sha string) bool {
+	p, err := c.zipballPath(owner, repo, sha)
+	if err != nil {
+		return false
+	}
+	if _, err := os.Stat(p); err != nil {
+		return false
+	}
+	_, err = os.Stat(p + digestSuffix)
+	return err == nil
+}
+
+func (c *Cache) FetchZipball(owner, repo, sha, dst string) (bool, error) {
+	p, err := c.zipballPath(owner, repo, sha)
+	if err != nil {
+		return false, err
+	}
+	want, err := os.ReadFile(p + digestSuffix)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := copyFile(p, dst); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	got, err := fsutil.HashFile(dst)
+	if err != nil {
+		return false, err
+	}
+	if got != strings.TrimSpace(string(want)) {
+		_ = os.Remove(dst)
+		return false, removeEntry(p)
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(p, now, now)
+	return true, nil
+}
+
+func (c *Cache) StoreZipball(owner, repo, sha, src string) error {
+	p, err := c.zipballPath(owner, repo, sha)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	digest, err := fsutil.HashFile(src)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".gdpm-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := copyFile(src, tmpName); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, p); err != nil {
+		return err
+	}
+	if err := fsutil.WriteFileAtomic(p+digestSuffix, []byte(digest+"\n"), 0o644); err != nil {
+		return err
+	}
+
+	if c.maxSize > 0 {
+		if _, err := c.Evict(c.maxSize); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cache) List() ([]Entry, error) {
+	root := filepath.Join(c.dir, zipballsDir)
+	owners, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, owner := range owners {
+		if !owner.IsDir() {
+			continue
+		}
+		repos, err := os.ReadDir(filepath.Join(root, owner.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range repos {
+			if !repo.IsDir() {
+				continue
+			}
+			files, err := os.ReadDir(filepath.Join(root, owner.Name(), repo.Name()))
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range files {
+				name := f.Name()
+				if !f.Type().IsRegular() || !strings.HasSuffix(name, zipballExt) {
+					continue
+				}
+				info, err := f.Info()
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, Entry{
+					Owner:    owner.Name(),
+					Repo:     repo.Name(),
+					SHA:      strings.TrimSuffix(name, zipballExt),
+					Path:     filepath.Join(root, owner.Name(), repo.Name(), name),
+					Size:     info.Size(),
+					LastUsed: info.ModTime(),
+				})
+			}
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key() < entries[j].Key()
+	})
+	return entries, nil
+}
+
+func (c *Cache) Clean() ([]Entry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	if err := fsutil.RemoveAll(filepath.Join(c.dir, zipballsDir)); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (c *Cache) Verify() (int, []Entry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var corrupt []Entry
+	for _, e := range entries {
+		want, err := os.ReadFile(e.Path + digestSuffix)
+		if err != nil && !os.IsNotExist(err) {
+			return 0, nil, err
+		}
+		got, err := fsutil.HashFile(e.Path)
+		if err != nil {
+			return 0, nil, err
+		}
+		if got == strings.TrimSpace(string(want)) {
+			continue
+		}
+		if err := removeEntry(e.Path); err != nil {
+			return 0, nil, err
+		}
+		corrupt = append(corrupt, e)
+	}
+	return len(entries), corrupt, nil
+}
+
+func (c *Cache) Evict(maxSize int64) ([]Entry, error) {
+	// Install workers store zipballs in parallel; one eviction at a time keeps
+	// them from sizing up and deleting the same entries twice.
+	c.evictMu.Lock()
+	defer c.evictMu.Unlock()
+
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+	if total <= maxSize {
+		return nil, nil
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastUsed.Before(entries[j].LastUsed)
+	})
+	var evicted []Entry
+	for _, e := range entries {
+		if total <= maxSize {
+			break
+		}
+		if err := removeEntry(e.Path); err != nil {
+			return evicted, err
+		}
+		total -= e.Size
+		evicted = append(evicted, e)
+	}
+	return evicted, nil
+}
+
+func removeEntry(zipPath string) error {
+	if err := os.Remove(zipPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(zipPath + digestSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func ParseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return 0, errors.New("empty size")
+	}
+
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		factor int64
+	}{
+		{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+		{"B", 1},
+	} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSHA = "0123456789abcdef0123456789abcdef01234567"
+
+func writeTestZip(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return p
+}
+
+func TestStoreAndFetchZipball(t *testing.T) {
+	c := New(t.TempDir(), 0)
+	work := t.TempDir()
+
+	dst := filepath.Join(work, "out.zip")
+	if ok, err := c.FetchZipball("owner", "repo", testSHA, dst); err != nil || ok {
+		t.Fatalf("expected miss, got ok=%v err=%v", ok, err)
+	}
+
+	src := writeTestZip(t, work, "src.zip", "zip bytes")
+	if err := c.StoreZipball("owner", "repo", testSHA, src); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	if ok, err := c.FetchZipball("owner", "repo", testSHA, dst); err != nil || !ok {
+		t.Fatalf("expected hit, got ok=%v err=%v", ok, err)
+	}
+	if b, err := os.ReadFile(dst); err != nil || string(b) != "zip bytes" {
+		t.Fatalf("unexpected fetched content %q (err=%v)", b, err)
+	}
+
+	entries, err := c.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Key() != "owner/repo@"+testSHA || entries[0].Size != int64(len("zip bytes")) {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	if err := c.StoreZipball("owner", "..", testSHA, src); err == nil {
+		t.Fatalf("expected invalid key error")
+	}
+	if err := c.StoreZipball("owner", "repo", "main", src); err == nil {
+		t.Fatalf("expected non-SHA ref to be rejected")
+	}
+}
+
+func TestVerifyRemovesCorruptEntries(t *testing.T) {
+	c := New(t.TempDir(), 0)
+	work := t.TempDir()
+
+	src := writeTestZip(t, work, "src.zip", "zip bytes")
+	if err := c.StoreZipball("owner", "repo", testSHA, src); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+	entries, err := c.List()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("list: %v (%d entries)", err, len(entries))
+	}
+	if err := os.WriteFile(entries[0].Path, []byte("tampered"), 0o644); err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+
+	checked, corrupt, err := c.Verify()
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if checked != 1 || len(corrupt) != 1 {
+		t.Fatalf("expected 1 corrupt of 1, got %d of %d", len(corrupt), checked)
+	}
+	if entries, err := c.List(); err != nil || len(entries) != 0 {
+		t.Fatalf("expected corrupt entry to be removed, got %+v (err=%v)", entries, err)
+	}
+}
+
+func TestEvictRemovesLeastRecentlyUsed(t *testing.T) {
+	c := New(t.TempDir(), 0)
+	work := t.TempDir()
+
+	shas := []string{
+		strings.Repeat("a", 40),
+		strings.Repeat("b", 40),
+		strings.Repeat("c", 40),
+	}
+	base := time.Now().Add(-time.Hour)
+	for i, sha := range shas {
+		src := writeTestZip(t, work, sha+".zip", "0123456789")
+		if err := c.StoreZipball("owner", "repo", sha, src); err != nil {
+			t.Fatalf("store: %v", err)
+		}
+		p, _ := c.zipballPath("owner", "repo", sha)
+		used := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(p, used, used); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+
+	evicted, err := c.Evict(20)
+	if err != nil {
+		t.Fatalf("evict: %v", err)
+	}
+	if len(evicted) != 1 || evicted[0].SHA != shas[0] {
+		t.Fatalf("expected oldest entry to be evicted, got %+v", evicted)
+	}
+}
+
+func TestStoreZipball_EvictsSafelyFromParallelWorkers(t *testing.T) {
+	c := New(t.TempDir(), 20)
+	work := t.TempDir()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		src := writeTestZip(t, work, fmt.Sprintf("%d.zip", i), "0123456789")
+		sha := fmt.Sprintf("%040x", i+1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- c.StoreZipball("owner", "repo", sha, src)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("store: %v", err)
+		}
+	}
+
+	entries, err := c.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+	if total > 20 || len(entries) == 0 {
+		t.Fatalf("expected cache to be trimmed to 20 bytes without emptying it, got %d bytes in %d entries", total, len(entries))
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"0":      0,
+		"1024":   1024,
+		"512MB":  512 << 20,
+		"2g":     2 << 30,
+		"1 GiB":  1 << 30,
+		"100 kb": 100 << 10,
+	}
+	for in, want := range tests {
+		got, err := ParseSize(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "abc", "-1", "1TB"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Fatalf("expected ParseSize(%q) to fail", in)
+		}
+	}
+}
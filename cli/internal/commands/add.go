@@ -10,14 +10,15 @@ import (
 
 	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
 	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
-	"github.com/aviorstudio/gdpm/cli/internal/githubapi"
 	"github.com/aviorstudio/gdpm/cli/internal/manifest"
 	"github.com/aviorstudio/gdpm/cli/internal/project"
 	"github.com/aviorstudio/gdpm/cli/internal/spec"
 )
 
 type AddOptions struct {
-	Spec string
+	Spec       string
+	As         string
+	Prerelease bool
 }
 
 func Add(ctx context.Context, opts AddOptions) error {
@@ -25,9 +26,14 @@ func Add(ctx context.Context, opts AddOptions) error {
 	if specInput == "" {
 		return fmt.Errorf("%w: missing plugin spec", ErrUserInput)
 	}
-	if !strings.HasPrefix(specInput, "@") {
+	fromPath := isLocalPathSpec(specInput)
+	fromRepo := !fromPath && isRepoURLSpec(specInput)
+	if !fromPath && !fromRepo && !strings.HasPrefix(specInput, "@") {
 		specInput = "@" + specInput
 	}
+	if !fromPath && !fromRepo && strings.TrimSpace(opts.As) != "" {
+		return fmt.Errorf("%w: --as only applies when adding from a repository URL or local path", ErrUserInput)
+	}
 
 	startDir, err := os.Getwd()
 	if err != nil {
@@ -42,107 +48,135 @@ func Add(ctx context.Context, opts AddOptions) error {
 		return fmt.Errorf("%w: no gdpm.json found (run `gdpm init`)", ErrUserInput)
 	}
 
+	unlock, err := lockProject(ctx, projectDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	manifestPath := filepath.Join(projectDir, "gdpm.json")
 	m, err := manifest.Load(manifestPath)
 	if err != nil {
 		return err
 	}
-
-	pkg, err := spec.ParsePackageSpec(specInput)
+	tx, err := beginTransaction(projectDir)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrUserInput, err)
+		return err
 	}
+	defer tx.rollback()
 
-	existing, hasExisting := m.Plugins[pkg.Name()]
-	isLinked := hasExisting && pluginLinkEnabled(existing)
+	if fromPath {
+		if err := addPathPlugin(ctx, tx, projectDir, m, specInput, opts.As); err != nil {
+			return err
+		}
+		return tx.commit()
+	}
 
-	db := gdpmdb.NewDefaultClient()
+	var (
+		pluginKey       string
+		resolved        gdpmdb.ResolvedPlugin
+		manifestVersion string
+		source          string
+	)
+	if fromRepo {
+		pluginKey, resolved, err = resolveRepoURLSpec(ctx, specInput, opts.As)
+		if err != nil {
+			return err
+		}
+		manifestVersion = resolved.Version
+		source = manifest.SourceRepo
+	} else {
+		pkg, err := spec.ParsePackageSpec(specInput)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUserInput, err)
+		}
+		if err := validateVersionConstraint(pkg.Version); err != nil {
+			return err
+		}
 
-	resolved, err := db.ResolvePlugin(ctx, pkg.Owner, pkg.Repo, pkg.Version)
-	if err != nil {
-		return fmt.Errorf("%w: %v", ErrUserInput, err)
+		db, err := openRegistry()
+		if err != nil {
+			return err
+		}
+		resolved, err = resolvePluginVersion(ctx, db, pkg, pkg.Version, opts.Prerelease)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUserInput, err)
+		}
+		pluginKey = pkg.Name()
+		manifestVersion = manifestVersionFor(pkg.Version, resolved.Version)
 	}
 
+	existing, hasExisting := m.Plugins[pluginKey]
+	isLinked := hasExisting && pluginLinkEnabled(existing)
+
 	if isLinked {
-		existing.Repo = gdpmdb.GitHubTreeURLWithPath(resolved.GitHubOwner, resolved.GitHubRepo, resolved.SHA, resolved.GitHubSubdir)
-		existing.Version = resolved.Version
-		m = manifest.UpsertPlugin(m, pkg.Name(), existing)
+		existing.Repo = repoURLForResolved(resolved)
+		existing.Version = manifestVersion
+		existing.Source = source
+		m = manifest.UpsertPlugin(m, pluginKey, existing)
 		if err := manifest.Save(manifestPath, m); err != nil {
 			return err
 		}
-		fmt.Printf("updated %s@%s (linked)\n", pkg.Name(), resolved.Version)
-		return nil
+		locked := lockedSourceForResolved(resolved)
+		if err := setProjectLockedPlugin(projectDir, pluginKey, &locked); err != nil {
+			return err
+		}
+		fmt.Printf("updated %s (linked)\n", pluginWithVersion(pluginKey, resolved.Version))
+		if err := syncDependencies(ctx, tx, projectDir); err != nil {
+			return err
+		}
+		return tx.commit()
 	}
 
-	tmpDir, err := os.MkdirTemp("", "gdpm-add-*")
+	addonDirName, err := addonDirNameForPluginKey(pluginKey)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %v", ErrUserInput, err)
 	}
-	defer os.RemoveAll(tmpDir)
 
-	zipPath := filepath.Join(tmpDir, "repo.zip")
-	gh := githubapi.NewClient(os.Getenv("GITHUB_TOKEN"))
-	if err := gh.DownloadZipball(ctx, resolved.GitHubOwner, resolved.GitHubRepo, resolved.SHA, zipPath); err != nil {
+	if err := validateNoAddonDirCollision(m, pluginKey, addonDirName); err != nil {
 		return err
 	}
 
-	extractDir := filepath.Join(tmpDir, "extract")
-	rootDir, err := fsutil.ExtractZip(zipPath, extractDir)
+	tmpDir, err := os.MkdirTemp("", "gdpm-add-*")
 	if err != nil {
 		return err
 	}
+	defer os.RemoveAll(tmpDir)
 
-	pkgRootDir, err := repoSubdirRoot(rootDir, resolved.GitHubSubdir)
+	fetcher, err := newPackageFetcher(false)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrUserInput, err)
-	}
-
-	localAddonsDir := filepath.Join(projectDir, "addons")
-	if err := os.MkdirAll(localAddonsDir, 0o755); err != nil {
 		return err
 	}
-
-	addonDirName, err := addonDirNameForPluginKey(pkg.Name())
+	locked := lockedSourceForResolved(resolved)
+	fetched, err := fetcher.fetchLockedPackage(ctx, tmpDir, locked, addonDirName)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrUserInput, err)
-	}
-
-	if err := validateNoAddonDirCollision(m, pkg.Name(), addonDirName); err != nil {
 		return err
 	}
 
-	if ok, err := pluginCfgExistsAtDirRoot(pkgRootDir); err != nil {
-		return fmt.Errorf("%w: %v", ErrUserInput, err)
-	} else if !ok {
-		expected := "res://" + path.Join("addons", addonDirName, "plugin.cfg")
-		if strings.TrimSpace(resolved.GitHubSubdir) != "" {
-			return fmt.Errorf("%w: package is missing plugin.cfg at %s in repository (expected to install it to %s)", ErrUserInput, resolved.GitHubSubdir, expected)
-		}
-		return fmt.Errorf("%w: package is missing plugin.cfg at repository root (expected to install it to %s)", ErrUserInput, expected)
-	}
-
-	dst := filepath.Join(localAddonsDir, addonDirName)
-	if manifest.HasPlugin(m, pkg.Name()) {
-		if err := fsutil.RemoveAll(dst); err != nil {
+	dst := filepath.Join(projectDir, "addons", addonDirName)
+	if !manifest.HasPlugin(m, pluginKey) {
+		// A plugin already installed as a dependency can be added directly;
+		// its directory belongs to gdpm and is replaced like any other.
+		lock, _, err := loadProjectLock(projectDir)
+		if err != nil {
 			return err
 		}
-	} else {
-		if _, err := os.Lstat(dst); err == nil {
-			return fmt.Errorf("%w: destination already exists: %s", ErrUserInput, dst)
-		} else if !os.IsNotExist(err) {
-			return err
+		if !containsString(lockedDependencyKeys(m, lock), pluginKey) {
+			if _, err := os.Lstat(dst); err == nil {
+				return fmt.Errorf("%w: destination already exists: %s", ErrUserInput, dst)
+			} else if !os.IsNotExist(err) {
+				return err
+			}
 		}
 	}
 
-	if err := fsutil.CopyPath(pkgRootDir, dst); err != nil {
+	if err := tx.copyAddon(fetched.rootDir, dst); err != nil {
 		return err
 	}
 
 	if ok, err := pluginCfgExistsAtDirRoot(dst); err != nil {
-		_ = fsutil.RemoveAll(dst)
 		return fmt.Errorf("%w: %v", ErrUserInput, err)
 	} else if !ok {
-		_ = fsutil.RemoveAll(dst)
 		return fmt.Errorf("%w: installed addon is missing plugin.cfg at %s", ErrUserInput, filepath.Join(dst, "plugin.cfg"))
 	}
 
@@ -150,15 +184,26 @@ func Add(ctx context.Context, opts AddOptions) error {
 	if hasExisting {
 		link = existing.Link
 	}
-	m = manifest.UpsertPlugin(m, pkg.Name(), manifest.Plugin{
-		Repo:    gdpmdb.GitHubTreeURLWithPath(resolved.GitHubOwner, resolved.GitHubRepo, resolved.SHA, resolved.GitHubSubdir),
-		Version: resolved.Version,
+	m = manifest.UpsertPlugin(m, pluginKey, manifest.Plugin{
+		Repo:    repoURLForResolved(resolved),
+		Version: manifestVersion,
+		Source:  source,
 		Link:    link,
 	})
 	if err := manifest.Save(manifestPath, m); err != nil {
 		return err
 	}
 
+	treeHash, err := fsutil.HashTree(fetched.rootDir)
+	if err != nil {
+		return err
+	}
+	locked.Zipball = fetched.zipballDigest
+	locked.Tree = treeHash
+	if err := setProjectLockedPlugin(projectDir, pluginKey, &locked); err != nil {
+		return err
+	}
+
 	projectGodotPath := filepath.Join(projectDir, "project.godot")
 	if _, err := os.Stat(projectGodotPath); err == nil {
 		pluginCfgResPath := "res://" + path.Join("addons", addonDirName, "plugin.cfg")
@@ -173,6 +218,16 @@ func Add(ctx context.Context, opts AddOptions) error {
 		return err
 	}
 
-	fmt.Printf("installed %s@%s (%s)\n", pkg.Name(), resolved.Version, resolved.SHA)
-	return nil
+	fmt.Printf("installed %s (%s)\n", pluginWithVersion(pluginKey, resolved.Version), resolved.SHA)
+	if err := syncDependencies(ctx, tx, projectDir); err != nil {
+		return err
+	}
+	return tx.commit()
+}
+
+func pluginWithVersion(pluginKey, version string) string {
+	if version = strings.TrimSpace(version); version != "" {
+		return pluginKey + "@" + version
+	}
+	return pluginKey
 }
@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aviorstudio/gdpm/cli/internal/cache"
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+)
+
+func TestAdd_ResolvesFromIndexRegistryWithDependencies(t *testing.T) {
+	projectDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv(cache.DirEnv, cacheDir)
+
+	uiSHA := strings.Repeat("1", 40)
+	tweenSHA := strings.Repeat("2", 40)
+
+	workDir := t.TempDir()
+	uiZip := filepath.Join(workDir, "ui.zip")
+	writeTestZipball(t, uiZip, "studio-ui_kit-1111111", map[string]string{
+		"plugin.cfg": "[plugin]\n",
+		"gdpm.json":  `{"plugins":{"@studio/tween":{"version":"^0.3.0"}}}`,
+	})
+	tweenZip := filepath.Join(workDir, "tween.zip")
+	writeTestZipball(t, tweenZip, "studio-tween-2222222", map[string]string{"plugin.cfg": "[plugin]\n"})
+
+	c := cache.New(cacheDir, 0)
+	if err := c.StoreZipball("studio", "ui_kit", uiSHA, uiZip); err != nil {
+		t.Fatalf("store ui zipball: %v", err)
+	}
+	if err := c.StoreZipball("studio", "tween", tweenSHA, tweenZip); err != nil {
+		t.Fatalf("store tween zipball: %v", err)
+	}
+
+	indexPath := filepath.Join(workDir, "index.json")
+	index := `{"plugins":{
+  "@studio/ui_kit":{"repo":"https://github.com/studio/ui_kit","versions":[{"version":"1.0.0","sha":"` + uiSHA + `"}]},
+  "@studio/tween":{"repo":"https://github.com/studio/tween","versions":[{"version":"0.3.2","sha":"` + tweenSHA + `"},{"version":"0.4.0","sha":"` + strings.Repeat("3", 40) + `"}]}
+}}`
+	if err := os.WriteFile(indexPath, []byte(index), 0o644); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+	t.Setenv(gdpmdb.RegistryEnv, indexPath)
+
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), manifest.New()); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Add(context.Background(), AddOptions{Spec: "@studio/ui_kit"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	m, err := manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		t.Fatalf("load gdpm.json: %v", err)
+	}
+	if plugin := m.Plugins["@studio/ui_kit"]; plugin.Version != "^1.0.0" {
+		t.Fatalf("unexpected manifest entry: %+v", plugin)
+	}
+	if _, ok := m.Plugins["@studio/tween"]; ok {
+		t.Fatalf("expected transitive plugin to stay out of gdpm.json")
+	}
+
+	lock, err := manifest.LoadLock(filepath.Join(projectDir, manifest.LockFilename))
+	if err != nil {
+		t.Fatalf("load lock: %v", err)
+	}
+	if tween := lock.Plugins["@studio/tween"]; tween.Version != "0.3.2" || tween.SHA != tweenSHA || tween.Tree == "" {
+		t.Fatalf("unexpected transitive lock entry: %+v", tween)
+	}
+	if deps := lock.Plugins["@studio/ui_kit"].Dependencies; deps["@studio/tween"] != "^0.3.0" {
+		t.Fatalf("expected dependencies to be recorded, got %v", deps)
+	}
+	for _, dir := range []string{"@studio_ui_kit", "@studio_tween"} {
+		if _, err := os.Stat(filepath.Join(projectDir, "addons", dir, "plugin.cfg")); err != nil {
+			t.Fatalf("expected %s to be installed: %v", dir, err)
+		}
+	}
+}
+
+func TestAdd_PromotesInstalledDependency(t *testing.T) {
+	projectDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv(cache.DirEnv, cacheDir)
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@studio/ui_kit", manifest.Plugin{})
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+	writeTestAddon(t, projectDir, "@studio/ui_kit", `{"plugins":{"@studio/tween":{"version":"^0.3.0"}}}`)
+	writeTestAddon(t, projectDir, "@studio/tween", "")
+	lock := manifest.NewLock()
+	lock.Plugins["@studio/ui_kit"] = manifest.LockedPlugin{Owner: "studio", Repo: "ui_kit", SHA: "abc", Dependencies: map[string]string{"@studio/tween": "^0.3.0"}}
+	lock.Plugins["@studio/tween"] = lockedTestAddon(t, projectDir, "@studio/tween", "0.3.0")
+	if err := manifest.SaveLock(filepath.Join(projectDir, manifest.LockFilename), lock); err != nil {
+		t.Fatalf("write gdpm.lock: %v", err)
+	}
+
+	tweenSHA := strings.Repeat("2", 40)
+	tweenZip := filepath.Join(t.TempDir(), "tween.zip")
+	writeTestZipball(t, tweenZip, "studio-tween-2222222", map[string]string{
+		"plugin.cfg": "[plugin]\n",
+		"tween.gd":   "extends Node\n",
+	})
+	if err := cache.New(cacheDir, 0).StoreZipball("studio", "tween", tweenSHA, tweenZip); err != nil {
+		t.Fatalf("store tween zipball: %v", err)
+	}
+	writeTestIndex(t, `"@studio/tween":{"repo":"https://github.com/studio/tween","versions":[{"version":"0.3.2","sha":"`+tweenSHA+`"}]}`)
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Add(context.Background(), AddOptions{Spec: "@studio/tween"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	m, err = manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		t.Fatalf("load gdpm.json: %v", err)
+	}
+	if plugin, ok := m.Plugins["@studio/tween"]; !ok || plugin.Version != "^0.3.2" {
+		t.Fatalf("expected @studio/tween to be a direct plugin, got %+v", plugin)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "addons", "@studio_tween", "tween.gd")); err != nil {
+		t.Fatalf("expected the added version to be installed: %v", err)
+	}
+
+	// A directory that gdpm does not know about is still refused.
+	writeTestAddon(t, projectDir, "@studio/other", "")
+	if err := cache.New(cacheDir, 0).StoreZipball("studio", "other", tweenSHA, tweenZip); err != nil {
+		t.Fatalf("store other zipball: %v", err)
+	}
+	writeTestIndex(t, `"@studio/other":{"repo":"https://github.com/studio/other","versions":[{"version":"1.0.0","sha":"`+tweenSHA+`"}]}`)
+	if err := Add(context.Background(), AddOptions{Spec: "@studio/other"}); err == nil || !strings.Contains(err.Error(), "destination already exists") {
+		t.Fatalf("expected add over an unknown directory to fail, got %v", err)
+	}
+}
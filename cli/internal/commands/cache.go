@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/aviorstudio/gdpm/cli/internal/cache"
+)
+
+type CacheListOptions struct{}
+
+type CacheCleanOptions struct{}
+
+type CacheVerifyOptions struct{}
+
+func CacheList(ctx context.Context, opts CacheListOptions) error {
+	_ = ctx
+	_ = opts
+
+	c, err := cache.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	entries, err := c.List()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Printf("cache is empty (%s)\n", c.Dir())
+		return nil
+	}
+
+	var total int64
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Source\tSize\tLast used\t")
+	for _, e := range entries {
+		total += e.Size
+		fmt.Fprintf(w, "%s\t%s\t%s\t\n", e.Key(), formatSize(e.Size), e.LastUsed.Format("2006-01-02 15:04"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d entries, %s in %s\n", len(entries), formatSize(total), c.Dir())
+	return nil
+}
+
+func CacheClean(ctx context.Context, opts CacheCleanOptions) error {
+	_ = ctx
+	_ = opts
+
+	c, err := cache.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	removed, err := c.Clean()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, e := range removed {
+		total += e.Size
+	}
+	fmt.Printf("removed %d entries (%s)\n", len(removed), formatSize(total))
+	return nil
+}
+
+func CacheVerify(ctx context.Context, opts CacheVerifyOptions) error {
+	_ = ctx
+	_ = opts
+
+	c, err := cache.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	checked, corrupt, err := c.Verify()
+	if err != nil {
+		return err
+	}
+	for _, e := range corrupt {
+		fmt.Printf("removed corrupt entry %s\n", e.Key())
+	}
+	fmt.Printf("verified %d entries, removed %d\n", checked, len(corrupt))
+	return nil
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
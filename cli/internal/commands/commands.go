@@ -3,3 +3,7 @@ package commands
 import "errors"
 
 var ErrUserInput = errors.New("user input error")
+
+var ErrOutdated = errors.New("plugins are outdated")
+
+var ErrUnhealthy = errors.New("project has problems")
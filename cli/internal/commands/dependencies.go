@@ -0,0 +1,497 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+	"github.com/aviorstudio/gdpm/cli/internal/semver"
+	"github.com/aviorstudio/gdpm/cli/internal/spec"
+)
+
+type dependencyRequirement struct {
+	constraint string
+	path       []string
+}
+
+func (r dependencyRequirement) String() string {
+	constraint := r.constraint
+	if constraint == "" {
+		constraint = "*"
+	}
+	return constraint + " required by " + strings.Join(r.path, " > ")
+}
+
+// selectedDependency is the version picked for a transitive dependency
+// while the graph is solved. Nothing is installed until every requirement
+// agrees, so a later requirement can still move the choice.
+type selectedDependency struct {
+	locked  manifest.LockedPlugin
+	deps    map[string]string
+	fetched *fetchedPackage
+	replace bool
+}
+
+// maxDependencyRounds bounds how often the solver re-selects versions before
+// giving up on requirements that keep moving each other.
+const maxDependencyRounds = 32
+
+type dependencyResolver struct {
+	tx         *transaction
+	projectDir string
+	fetcher    *packageFetcher
+	m          manifest.Manifest
+	prevLock   manifest.Lock
+	lock       manifest.Lock
+	mode       installMode
+	workDir    string
+	fetches    int
+
+	edges        map[string]map[string]string
+	paths        map[string][]string
+	requirements map[string][]dependencyRequirement
+	selected     map[string]selectedDependency
+}
+
+func syncDependencies(ctx context.Context, tx *transaction, projectDir string) error {
+	m, err := manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		return err
+	}
+	lock, lockExists, err := loadProjectLock(projectDir)
+	if err != nil {
+		return err
+	}
+
+	nextLock := manifest.NewLock()
+	for pluginKey, locked := range lock.Plugins {
+		if _, ok := m.Plugins[pluginKey]; ok {
+			nextLock.Plugins[pluginKey] = locked
+		}
+	}
+	fetcher, err := newPackageFetcher(false)
+	if err != nil {
+		return err
+	}
+	if err := resolveDependencies(ctx, tx, projectDir, fetcher, m, lock, nextLock, installMode{}); err != nil {
+		return err
+	}
+	if err := removeUnneededDependencies(tx, projectDir, m, lock, nextLock); err != nil {
+		return err
+	}
+	return saveProjectLockIfChanged(projectDir, nextLock, lock, lockExists)
+}
+
+func removeUnneededDependencies(tx *transaction, projectDir string, m manifest.Manifest, prevLock, lock manifest.Lock) error {
+	var names []string
+	for _, pluginKey := range sortedKeys(prevLock.Plugins) {
+		if _, ok := m.Plugins[pluginKey]; ok {
+			continue
+		}
+		if _, ok := lock.Plugins[pluginKey]; ok {
+			continue
+		}
+		addonDirName, err := addonDirNameForPluginKey(pluginKey)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(projectDir, "addons", addonDirName)); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		names = append(names, addonDirName)
+	}
+	return pruneAddonDirs(tx, projectDir, names)
+}
+
+func resolveDependencies(ctx context.Context, tx *transaction, projectDir string, fetcher *packageFetcher, m manifest.Manifest, prevLock, lock manifest.Lock, mode installMode) error {
+	workDir, err := os.MkdirTemp("", "gdpm-deps-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	r := &dependencyResolver{
+		tx:         tx,
+		projectDir: projectDir,
+		fetcher:    fetcher,
+		m:          m,
+		prevLock:   prevLock,
+		lock:       lock,
+		mode:       mode,
+		workDir:    workDir,
+		edges:      map[string]map[string]string{},
+		selected:   map[string]selectedDependency{},
+	}
+
+	for _, pluginKey := range sortedKeys(m.Plugins) {
+		addonDirName, err := addonDirNameForPluginKey(pluginKey)
+		if err != nil {
+			return fmt.Errorf("%w: invalid plugin key in gdpm.json: %s (%v)", ErrUserInput, pluginKey, err)
+		}
+		dir := filepath.Join(projectDir, "addons", addonDirName)
+		if _, err := os.Stat(dir); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		deps, err := manifest.LoadDependencies(dir)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUserInput, err)
+		}
+		r.edges[pluginKey] = deps
+		if locked, ok := lock.Plugins[pluginKey]; ok && !pluginLinkEnabled(m.Plugins[pluginKey]) {
+			locked.Dependencies = deps
+			lock.Plugins[pluginKey] = locked
+		}
+	}
+
+	if err := r.solve(ctx); err != nil {
+		return err
+	}
+	for pluginKey := range r.requirements {
+		r.edges[pluginKey] = r.selected[pluginKey].deps
+	}
+	if err := r.checkCycles(); err != nil {
+		return err
+	}
+	if err := r.install(ctx); err != nil {
+		return err
+	}
+
+	if mode.frozen {
+		var stale []string
+		for pluginKey := range prevLock.Plugins {
+			if _, ok := lock.Plugins[pluginKey]; !ok {
+				stale = append(stale, pluginKey)
+			}
+		}
+		if len(stale) != 0 {
+			sort.Strings(stale)
+			return fmt.Errorf("%w: %s has entries that are no longer required: %s (run `gdpm install` without --frozen)", ErrUserInput, manifest.LockFilename, strings.Join(stale, ", "))
+		}
+	}
+	return nil
+}
+
+// solve picks a version for every transitive dependency. Each round gathers
+// the requirements of the current selection and re-selects any dependency
+// whose version no longer satisfies all of them, until nothing moves.
+func (r *dependencyResolver) solve(ctx context.Context) error {
+	for round := 0; ; round++ {
+		if err := r.collect(); err != nil {
+			return err
+		}
+		var unsettled []string
+		for _, pluginKey := range sortedKeys(r.requirements) {
+			if sel, ok := r.selected[pluginKey]; !ok || !satisfiesAll(sel.locked.Version, r.requirements[pluginKey]) {
+				unsettled = append(unsettled, pluginKey)
+			}
+		}
+		if len(unsettled) == 0 {
+			return nil
+		}
+		if round == maxDependencyRounds {
+			return fmt.Errorf("%w: dependency versions did not settle for: %s", ErrUserInput, strings.Join(unsettled, ", "))
+		}
+		for _, pluginKey := range unsettled {
+			if err := r.choose(ctx, pluginKey); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// collect walks the graph from the plugins in gdpm.json through the current
+// selection and records every requirement on each dependency it reaches.
+func (r *dependencyResolver) collect() error {
+	r.requirements = map[string][]dependencyRequirement{}
+	r.paths = map[string][]string{}
+
+	queue := sortedKeys(r.edges)
+	for _, pluginKey := range queue {
+		r.paths[pluginKey] = []string{pluginKey}
+	}
+	for len(queue) != 0 {
+		pluginKey := queue[0]
+		queue = queue[1:]
+
+		deps := r.edges[pluginKey]
+		if sel, ok := r.selected[pluginKey]; ok {
+			deps = sel.deps
+		}
+		for _, depKey := range sortedKeys(deps) {
+			req := dependencyRequirement{constraint: deps[depKey], path: r.paths[pluginKey]}
+			if err := r.require(depKey, req); err != nil {
+				return err
+			}
+			if _, ok := r.paths[depKey]; ok {
+				continue
+			}
+			r.paths[depKey] = append(append([]string{}, req.path...), depKey)
+			if _, ok := r.m.Plugins[depKey]; !ok {
+				queue = append(queue, depKey)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *dependencyResolver) require(pluginKey string, req dependencyRequirement) error {
+	if _, err := spec.ParsePackageSpec(pluginKey); err != nil {
+		return fmt.Errorf("%w: invalid dependency %s (%s): %v", ErrUserInput, pluginKey, req, err)
+	}
+	if err := validateVersionConstraint(req.constraint); err != nil {
+		return fmt.Errorf("invalid dependency %s (%s): %w", pluginKey, req, err)
+	}
+
+	if plugin, ok := r.m.Plugins[pluginKey]; ok {
+		version := resolvedPluginVersion(r.lock, pluginKey, plugin)
+		if version != "" && !versionSatisfies(req.constraint, version) {
+			return fmt.Errorf("%w: %s@%s in gdpm.json does not satisfy %s", ErrUserInput, pluginKey, version, req)
+		}
+		return nil
+	}
+
+	r.requirements[pluginKey] = append(r.requirements[pluginKey], req)
+	return nil
+}
+
+func (r *dependencyResolver) choose(ctx context.Context, pluginKey string) error {
+	reqs := r.requirements[pluginKey]
+
+	addonDirName, err := addonDirNameForPluginKey(pluginKey)
+	if err != nil {
+		return fmt.Errorf("%w: invalid dependency %s: %v", ErrUserInput, pluginKey, err)
+	}
+	dst := filepath.Join(r.projectDir, "addons", addonDirName)
+
+	prev, hasPrev := r.prevLock.Plugins[pluginKey]
+	var locked manifest.LockedPlugin
+	switch {
+	case hasPrev && prev.Version != "" && satisfiesAll(prev.Version, reqs):
+		locked = prev
+	case r.mode.frozen || r.fetcher.offline:
+		if !hasPrev {
+			return fmt.Errorf("%w: %s has no entry for %s (%s)", ErrUserInput, manifest.LockFilename, pluginKey, reqs[0])
+		}
+		return r.conflictError(pluginKey, prev.Version)
+	default:
+		resolved, err := r.resolveFromRegistry(ctx, pluginKey, reqs)
+		if err != nil {
+			return err
+		}
+		locked = lockedSourceForResolved(resolved)
+	}
+
+	info, err := os.Lstat(dst)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if exists && !info.IsDir() && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%w: addon path exists and is not a directory: %s", ErrUserInput, dst)
+	}
+
+	replace := exists && hasPrev && !prev.SameCommit(locked)
+	if exists && !replace && info.IsDir() {
+		reinstall, err := checkInstalledTree(ctx, r.fetcher, r.mode, pluginKey, addonDirName, dst, &locked)
+		if err != nil {
+			return err
+		}
+		replace = reinstall
+	}
+	if exists && !replace {
+		deps, err := manifest.LoadDependencies(dst)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUserInput, err)
+		}
+		r.selected[pluginKey] = selectedDependency{locked: locked, deps: deps}
+		return nil
+	}
+
+	if r.mode.frozen && locked.Tree == "" {
+		return fmt.Errorf("%w: %s has no tree hash for %s (run `gdpm install` without --frozen)", ErrUserInput, manifest.LockFilename, pluginKey)
+	}
+	// The dependencies of a version that is not installed yet are read from
+	// the fetched package, which is installed only once the graph is solved.
+	r.fetches++
+	pkgDir := filepath.Join(r.workDir, fmt.Sprintf("pkg-%d", r.fetches))
+	if err := os.MkdirAll(pkgDir, 0o755); err != nil {
+		return err
+	}
+	fetched, err := r.fetcher.fetchLockedPackage(ctx, pkgDir, locked, addonDirName)
+	if err != nil {
+		return fmt.Errorf("%s: %w", pluginKey, err)
+	}
+	deps, err := manifest.LoadDependencies(fetched.rootDir)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	r.selected[pluginKey] = selectedDependency{locked: locked, deps: deps, fetched: &fetched, replace: replace}
+	return nil
+}
+
+func (r *dependencyResolver) install(ctx context.Context) error {
+	var candidates []installCandidate
+	for _, pluginKey := range sortedKeys(r.requirements) {
+		sel := r.selected[pluginKey]
+		locked := sel.locked
+		locked.Dependencies = sel.deps
+		r.lock.Plugins[pluginKey] = locked
+		if sel.fetched == nil {
+			continue
+		}
+
+		addonDirName, err := addonDirNameForPluginKey(pluginKey)
+		if err != nil {
+			return fmt.Errorf("%w: invalid dependency %s: %v", ErrUserInput, pluginKey, err)
+		}
+		candidates = append(candidates, installCandidate{
+			pluginKey:  pluginKey,
+			addonDir:   addonDirName,
+			dst:        filepath.Join(r.projectDir, "addons", addonDirName),
+			version:    locked.Version,
+			ghOwner:    locked.Owner,
+			ghRepo:     locked.Repo,
+			host:       locked.Host,
+			gitRemote:  locked.Git,
+			ref:        locked.SHA,
+			repoSubdir: locked.Subdir,
+			replace:    sel.replace,
+			fetched:    sel.fetched,
+		})
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	return installCandidates(ctx, r.tx, r.projectDir, r.fetcher, candidates, r.lock, r.mode.frozen, 1)
+}
+
+func (r *dependencyResolver) resolveFromRegistry(ctx context.Context, pluginKey string, reqs []dependencyRequirement) (gdpmdb.ResolvedPlugin, error) {
+	pkg, err := spec.ParsePackageSpec(pluginKey)
+	if err != nil {
+		return gdpmdb.ResolvedPlugin{}, fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+
+	db, err := openRegistry()
+	if err != nil {
+		return gdpmdb.ResolvedPlugin{}, err
+	}
+	versions, err := db.ListPluginVersions(ctx, pkg.Owner, pkg.Repo)
+	if err != nil {
+		return gdpmdb.ResolvedPlugin{}, fmt.Errorf("%w: %s (%s): %v", ErrUserInput, pluginKey, reqs[0], err)
+	}
+
+	var best semver.Version
+	bestSet := false
+	for _, v := range versions {
+		candidate, ok := semver.Parse(v.String())
+		if !ok || !satisfiesAll(v.String(), reqs) {
+			continue
+		}
+		if !bestSet || semver.Compare(candidate, best) > 0 {
+			best = candidate
+			bestSet = true
+		}
+	}
+	if !bestSet {
+		if len(reqs) == 1 {
+			return gdpmdb.ResolvedPlugin{}, fmt.Errorf("%w: no version of %s matches %s", ErrUserInput, pluginKey, reqs[0])
+		}
+		return gdpmdb.ResolvedPlugin{}, r.conflictError(pluginKey, "")
+	}
+
+	resolved, err := db.ResolvePlugin(ctx, pkg.Owner, pkg.Repo, best.String())
+	if err != nil {
+		return gdpmdb.ResolvedPlugin{}, fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	return resolved, nil
+}
+
+func (r *dependencyResolver) conflictError(pluginKey, selected string) error {
+	lines := []string{fmt.Sprintf("conflicting version requirements for %s:", pluginKey)}
+	for _, req := range r.requirements[pluginKey] {
+		lines = append(lines, "  "+req.String())
+	}
+	if selected != "" {
+		lines = append(lines, "  (locked at "+selected+")")
+	}
+	return fmt.Errorf("%w: %s", ErrUserInput, strings.Join(lines, "\n"))
+}
+
+func (r *dependencyResolver) checkCycles() error {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var stack []string
+
+	var walk func(pluginKey string) error
+	walk = func(pluginKey string) error {
+		switch state[pluginKey] {
+		case visiting:
+			start := 0
+			for i, key := range stack {
+				if key == pluginKey {
+					start = i
+					break
+				}
+			}
+			cycle := append(append([]string{}, stack[start:]...), pluginKey)
+			return fmt.Errorf("%w: dependency cycle: %s", ErrUserInput, strings.Join(cycle, " > "))
+		case done:
+			return nil
+		}
+
+		state[pluginKey] = visiting
+		stack = append(stack, pluginKey)
+		depKeys := make([]string, 0, len(r.edges[pluginKey]))
+		for depKey := range r.edges[pluginKey] {
+			depKeys = append(depKeys, depKey)
+		}
+		sort.Strings(depKeys)
+		for _, depKey := range depKeys {
+			if err := walk(depKey); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[pluginKey] = done
+		return nil
+	}
+
+	pluginKeys := make([]string, 0, len(r.edges))
+	for key := range r.edges {
+		pluginKeys = append(pluginKeys, key)
+	}
+	sort.Strings(pluginKeys)
+	for _, pluginKey := range pluginKeys {
+		if err := walk(pluginKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func satisfiesAll(version string, reqs []dependencyRequirement) bool {
+	if strings.TrimSpace(version) == "" {
+		return false
+	}
+	for _, req := range reqs {
+		if !versionSatisfies(req.constraint, version) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,269 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aviorstudio/gdpm/cli/internal/cache"
+	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+)
+
+func writeTestAddon(t *testing.T, projectDir, pluginKey, depsJSON string) {
+	t.Helper()
+
+	addonDirName, err := addonDirNameForPluginKey(pluginKey)
+	if err != nil {
+		t.Fatalf("addonDirNameForPluginKey: %v", err)
+	}
+	dst := filepath.Join(projectDir, "addons", addonDirName)
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		t.Fatalf("mkdir addons dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "plugin.cfg"), []byte("[plugin]\n"), 0o644); err != nil {
+		t.Fatalf("write plugin.cfg: %v", err)
+	}
+	if depsJSON != "" {
+		if err := os.WriteFile(filepath.Join(dst, "gdpm.json"), []byte(depsJSON), 0o644); err != nil {
+			t.Fatalf("write addon gdpm.json: %v", err)
+		}
+	}
+}
+
+func writeTestIndex(t *testing.T, plugins string) {
+	t.Helper()
+
+	indexPath := filepath.Join(t.TempDir(), "index.json")
+	if err := os.WriteFile(indexPath, []byte(`{"plugins":{`+plugins+`}}`), 0o644); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+	t.Setenv(gdpmdb.RegistryEnv, indexPath)
+}
+
+func lockedTestAddon(t *testing.T, projectDir, pluginKey, version string) manifest.LockedPlugin {
+	t.Helper()
+
+	addonDirName, err := addonDirNameForPluginKey(pluginKey)
+	if err != nil {
+		t.Fatalf("addonDirNameForPluginKey: %v", err)
+	}
+	treeHash, err := fsutil.HashTree(filepath.Join(projectDir, "addons", addonDirName))
+	if err != nil {
+		t.Fatalf("HashTree: %v", err)
+	}
+	owner, repo, _ := strings.Cut(strings.TrimPrefix(pluginKey, "@"), "/")
+	return manifest.LockedPlugin{Owner: owner, Repo: repo, SHA: "abc", Version: version, Tree: treeHash}
+}
+
+func TestResolveDependencies_ReportsConflictWithPaths(t *testing.T) {
+	projectDir := t.TempDir()
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@a/ui", manifest.Plugin{})
+	m = manifest.UpsertPlugin(m, "@b/game", manifest.Plugin{})
+	writeTestAddon(t, projectDir, "@a/ui", `{"plugins":{"@c/tween":{"version":"^1.0.0"}}}`)
+	writeTestAddon(t, projectDir, "@b/game", `{"plugins":{"@c/tween":{"version":"^2.0.0"}}}`)
+	writeTestAddon(t, projectDir, "@c/tween", "")
+
+	prevLock := manifest.NewLock()
+	prevLock.Plugins["@c/tween"] = lockedTestAddon(t, projectDir, "@c/tween", "1.2.0")
+	writeTestIndex(t, `"@c/tween":{"repo":"https://github.com/c/tween","versions":[{"version":"1.2.0","sha":"`+strings.Repeat("1", 40)+`"},{"version":"2.0.0","sha":"`+strings.Repeat("2", 40)+`"}]}`)
+
+	err := resolveDependencies(context.Background(), nil, projectDir, &packageFetcher{}, m, prevLock, manifest.NewLock(), installMode{})
+	if err == nil {
+		t.Fatalf("expected conflict error")
+	}
+	for _, want := range []string{"@c/tween", "^1.0.0 required by @a/ui", "^2.0.0 required by @b/game"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error to mention %q, got: %v", want, err)
+		}
+	}
+}
+
+func TestResolveDependencies_ReselectsForLaterRequirement(t *testing.T) {
+	projectDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv(cache.DirEnv, cacheDir)
+
+	// @a/ui asks for ^1.0.0 first, which picks 1.4.0; @b/helper, reached
+	// only through @a/ui, then narrows it to <1.3.0.
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@a/ui", manifest.Plugin{})
+	writeTestAddon(t, projectDir, "@a/ui", `{"plugins":{"@b/helper":{},"@c/tween":{"version":"^1.0.0"}}}`)
+
+	tween12 := strings.Repeat("1", 40)
+	tween14 := strings.Repeat("4", 40)
+	helper := strings.Repeat("b", 40)
+	workDir := t.TempDir()
+	c := cache.New(cacheDir, 0)
+	for _, pkg := range []struct {
+		owner, repo, sha string
+		files            map[string]string
+	}{
+		{"c", "tween", tween12, map[string]string{"plugin.cfg": "[plugin]\n", "version.txt": "1.2.0\n"}},
+		{"c", "tween", tween14, map[string]string{"plugin.cfg": "[plugin]\n", "version.txt": "1.4.0\n"}},
+		{"b", "helper", helper, map[string]string{"plugin.cfg": "[plugin]\n", "gdpm.json": `{"plugins":{"@c/tween":{"version":"<1.3.0"}}}`}},
+	} {
+		zipPath := filepath.Join(workDir, pkg.repo+"-"+pkg.sha[:7]+".zip")
+		writeTestZipball(t, zipPath, pkg.owner+"-"+pkg.repo+"-"+pkg.sha[:7], pkg.files)
+		if err := c.StoreZipball(pkg.owner, pkg.repo, pkg.sha, zipPath); err != nil {
+			t.Fatalf("store zipball: %v", err)
+		}
+	}
+	writeTestIndex(t, `"@c/tween":{"repo":"https://github.com/c/tween","versions":[{"version":"1.2.0","sha":"`+tween12+`"},{"version":"1.4.0","sha":"`+tween14+`"}]},
+  "@b/helper":{"repo":"https://github.com/b/helper","versions":[{"version":"1.0.0","sha":"`+helper+`"}]}`)
+
+	fetcher, err := newPackageFetcher(false)
+	if err != nil {
+		t.Fatalf("newPackageFetcher: %v", err)
+	}
+	tx, err := beginTransaction(projectDir)
+	if err != nil {
+		t.Fatalf("begin transaction: %v", err)
+	}
+	defer tx.rollback()
+
+	lock := manifest.NewLock()
+	if err := resolveDependencies(context.Background(), tx, projectDir, fetcher, m, manifest.NewLock(), lock, installMode{}); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if err := tx.commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	if locked := lock.Plugins["@c/tween"]; locked.Version != "1.2.0" || locked.SHA != tween12 || locked.Tree == "" {
+		t.Fatalf("expected @c/tween to settle on 1.2.0, got %+v", locked)
+	}
+	if locked := lock.Plugins["@b/helper"]; locked.Version != "1.0.0" || locked.Dependencies["@c/tween"] != "<1.3.0" {
+		t.Fatalf("unexpected @b/helper lock entry: %+v", locked)
+	}
+	data, err := os.ReadFile(filepath.Join(projectDir, "addons", "@c_tween", "version.txt"))
+	if err != nil || string(data) != "1.2.0\n" {
+		t.Fatalf("expected only 1.2.0 to be installed, got %q (err=%v)", data, err)
+	}
+}
+
+func TestResolveDependencies_DetectsCycle(t *testing.T) {
+	projectDir := t.TempDir()
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@a/ui", manifest.Plugin{})
+	writeTestAddon(t, projectDir, "@a/ui", `{"plugins":{"@c/tween":{"version":"^1.0.0"}}}`)
+	writeTestAddon(t, projectDir, "@c/tween", `{"plugins":{"@a/ui":{}}}`)
+
+	prevLock := manifest.NewLock()
+	prevLock.Plugins["@c/tween"] = lockedTestAddon(t, projectDir, "@c/tween", "1.2.0")
+
+	lock := manifest.NewLock()
+	err := resolveDependencies(context.Background(), nil, projectDir, &packageFetcher{}, m, prevLock, lock, installMode{})
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle: @a/ui > @c/tween > @a/ui") {
+		t.Fatalf("expected cycle error, got: %v", err)
+	}
+}
+
+func TestResolveDependencies_RecordsTransitiveLockEntries(t *testing.T) {
+	projectDir := t.TempDir()
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@a/ui", manifest.Plugin{})
+	writeTestAddon(t, projectDir, "@a/ui", `{"plugins":{"@c/tween":{"version":"^1.0.0"}}}`)
+	writeTestAddon(t, projectDir, "@c/tween", "")
+
+	prevLock := manifest.NewLock()
+	prevLock.Plugins["@c/tween"] = lockedTestAddon(t, projectDir, "@c/tween", "1.2.0")
+	prevLock.Plugins["@d/stale"] = manifest.LockedPlugin{Owner: "d", Repo: "stale", SHA: "def", Version: "1.0.0"}
+
+	lock := manifest.NewLock()
+	if err := resolveDependencies(context.Background(), nil, projectDir, &packageFetcher{}, m, prevLock, lock, installMode{}); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	locked, ok := lock.Plugins["@c/tween"]
+	if !ok || locked.Version != "1.2.0" || locked.Tree == "" {
+		t.Fatalf("expected @c/tween to be locked with a tree hash, got %+v", locked)
+	}
+	if _, ok := lock.Plugins["@d/stale"]; ok {
+		t.Fatalf("expected unreachable lock entry to be dropped")
+	}
+
+	lock.Plugins["@d/stale"] = prevLock.Plugins["@d/stale"]
+	err := resolveDependencies(context.Background(), nil, projectDir, &packageFetcher{}, m, lock, manifest.NewLock(), installMode{frozen: true})
+	if err == nil || !strings.Contains(err.Error(), "@d/stale") {
+		t.Fatalf("expected frozen resolve to reject unreachable lock entries, got: %v", err)
+	}
+}
+
+func TestRemove_RemovesDependenciesNothingElseNeeds(t *testing.T) {
+	projectDir := t.TempDir()
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@a/ui", manifest.Plugin{})
+	m = manifest.UpsertPlugin(m, "@b/game", manifest.Plugin{})
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+	writeTestAddon(t, projectDir, "@a/ui", `{"plugins":{"@c/tween":{"version":"^1.0.0"},"@d/shared":{}}}`)
+	writeTestAddon(t, projectDir, "@b/game", `{"plugins":{"@d/shared":{}}}`)
+	writeTestAddon(t, projectDir, "@c/tween", "")
+	writeTestAddon(t, projectDir, "@d/shared", "")
+
+	lock := manifest.NewLock()
+	lock.Plugins["@c/tween"] = lockedTestAddon(t, projectDir, "@c/tween", "1.0.0")
+	lock.Plugins["@d/shared"] = lockedTestAddon(t, projectDir, "@d/shared", "1.0.0")
+	lockPath := filepath.Join(projectDir, manifest.LockFilename)
+	if err := manifest.SaveLock(lockPath, lock); err != nil {
+		t.Fatalf("write gdpm.lock: %v", err)
+	}
+
+	projectGodotPath := filepath.Join(projectDir, "project.godot")
+	projectGodot := "config_version=5\n\n[editor_plugins]\nenabled=PackedStringArray(\"res://addons/@a_ui/plugin.cfg\", \"res://addons/@b_game/plugin.cfg\", \"res://addons/@c_tween/plugin.cfg\", \"res://addons/@d_shared/plugin.cfg\")\n"
+	if err := os.WriteFile(projectGodotPath, []byte(projectGodot), 0o644); err != nil {
+		t.Fatalf("write project.godot: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Remove(context.Background(), RemoveOptions{Spec: "@a/ui"}); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	for _, dir := range []string{"@a_ui", "@c_tween"} {
+		if _, err := os.Lstat(filepath.Join(projectDir, "addons", dir)); !os.IsNotExist(err) {
+			t.Fatalf("expected addons/%s to be removed, got err=%v", dir, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "addons", "@d_shared")); err != nil {
+		t.Fatalf("expected dependency still needed by @b/game to be kept: %v", err)
+	}
+
+	nextLock, err := manifest.LoadLock(lockPath)
+	if err != nil {
+		t.Fatalf("read gdpm.lock: %v", err)
+	}
+	if _, ok := nextLock.Plugins["@c/tween"]; ok {
+		t.Fatalf("expected @c/tween to be dropped from %s", manifest.LockFilename)
+	}
+	if _, ok := nextLock.Plugins["@d/shared"]; !ok {
+		t.Fatalf("expected @d/shared to stay in %s", manifest.LockFilename)
+	}
+
+	data, err := os.ReadFile(projectGodotPath)
+	if err != nil {
+		t.Fatalf("read project.godot: %v", err)
+	}
+	got := string(data)
+	if strings.Contains(got, "@c_tween") || strings.Contains(got, "@a_ui") || !strings.Contains(got, "@d_shared") {
+		t.Fatalf("expected only the removed plugin and its unneeded dependency to be disabled, got:\n%s", got)
+	}
+}
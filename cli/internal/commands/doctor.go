@@ -0,0 +1,413 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+	"github.com/aviorstudio/gdpm/cli/internal/project"
+)
+
+type DoctorOptions struct {
+	Fix bool
+}
+
+type doctorFinding struct {
+	problem string
+	hint    string
+	fixDesc string
+	fix     func(tx *transaction) error
+}
+
+func Doctor(ctx context.Context, opts DoctorOptions) error {
+	startDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	projectDir, ok := project.FindManifestDir(startDir)
+	if !ok {
+		return fmt.Errorf("%w: no gdpm.json found (run `gdpm init`)", ErrUserInput)
+	}
+
+	if opts.Fix {
+		unlock, err := lockProject(ctx, projectDir)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	findings, err := checkProject(projectDir)
+	if err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		fmt.Println("no problems found")
+		return nil
+	}
+
+	if !opts.Fix {
+		for _, f := range findings {
+			fmt.Println(f.problem)
+			if f.fix != nil {
+				fmt.Printf("  run `gdpm doctor --fix` to %s\n", f.fixDesc)
+			} else if f.hint != "" {
+				fmt.Printf("  %s\n", f.hint)
+			}
+		}
+		return ErrUnhealthy
+	}
+
+	tx, err := beginTransaction(projectDir)
+	if err != nil {
+		return err
+	}
+	defer tx.rollback()
+
+	remaining := 0
+	for _, f := range findings {
+		if f.fix == nil {
+			fmt.Println(f.problem)
+			if f.hint != "" {
+				fmt.Printf("  %s\n", f.hint)
+			}
+			remaining++
+			continue
+		}
+		if err := f.fix(tx); err != nil {
+			return err
+		}
+		fmt.Printf("fixed: %s\n", f.problem)
+	}
+	if err := tx.commit(); err != nil {
+		return err
+	}
+	if remaining != 0 {
+		return ErrUnhealthy
+	}
+	return nil
+}
+
+func checkProject(projectDir string) ([]doctorFinding, error) {
+	var findings []doctorFinding
+
+	holder, lockBusy, err := projectLockHolder(filepath.Join(projectDir, projectLockFilename))
+	if err != nil {
+		return nil, err
+	}
+	if lockBusy {
+		findings = append(findings, doctorFinding{
+			problem: fmt.Sprintf("another gdpm process (%s) is working on this project", holder),
+			hint:    "wait for it to finish, then run `gdpm doctor` again",
+		})
+	}
+
+	// Staging directories belong to a running command while the lock is held.
+	if !lockBusy {
+		staging, err := filepath.Glob(filepath.Join(projectDir, stagingDirPrefix+"*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range staging {
+			f := doctorFinding{
+				problem: fmt.Sprintf("%s was left behind by an interrupted gdpm command", filepath.Base(dir)),
+				hint:    "it may hold previous addon directories; move out anything you need, then delete it",
+			}
+			if stagingDirEmpty(dir) {
+				f.fixDesc = "delete it"
+				f.fix = func(*transaction) error {
+					return os.RemoveAll(dir)
+				}
+			}
+			findings = append(findings, f)
+		}
+	}
+
+	m, err := manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		findings = append(findings, doctorFinding{
+			problem: fmt.Sprintf("gdpm.json or %s cannot be read: %v", manifest.LinkFilename, err),
+			hint:    "fix the file by hand, then run `gdpm doctor` again",
+		})
+		return findings, nil
+	}
+
+	lock, _, err := loadProjectLock(projectDir)
+	if err != nil {
+		findings = append(findings, doctorFinding{
+			problem: fmt.Sprintf("%s cannot be read: %v", manifest.LockFilename, err),
+			hint:    fmt.Sprintf("fix or delete %s, then run `gdpm install`", manifest.LockFilename),
+		})
+		lock = manifest.NewLock()
+	} else if mismatched, err := lockMismatches(m, lock); err == nil && len(mismatched) != 0 {
+		findings = append(findings, doctorFinding{
+			problem: fmt.Sprintf("%s does not match gdpm.json for: %s", manifest.LockFilename, strings.Join(mismatched, ", ")),
+			hint:    "run `gdpm install`",
+		})
+	}
+
+	linkPath := filepath.Join(projectDir, manifest.LinkFilename)
+	if lm, err := manifest.LoadLinkManifest(linkPath); err == nil {
+		for _, pluginKey := range sortedKeys(lm.Plugins) {
+			if _, ok := m.Plugins[pluginKey]; ok {
+				continue
+			}
+			findings = append(findings, doctorFinding{
+				problem: fmt.Sprintf("%s has an entry for %s, which is not in gdpm.json", manifest.LinkFilename, pluginKey),
+				fixDesc: "remove the entry",
+				fix: func(*transaction) error {
+					return removeLinkEntry(linkPath, pluginKey)
+				},
+			})
+		}
+	}
+
+	addonsDir := filepath.Join(projectDir, "addons")
+	owned := map[string]string{}
+	for _, pluginKey := range sortedKeys(m.Plugins) {
+		addonDirName, err := addonDirNameForPluginKey(pluginKey)
+		if err != nil {
+			findings = append(findings, doctorFinding{
+				problem: fmt.Sprintf("gdpm.json has an invalid plugin name: %s", pluginKey),
+				hint:    "rename it to @owner/name",
+			})
+			continue
+		}
+		owned[addonDirName] = pluginKey
+		found, err := checkInstalledPlugin(projectDir, pluginKey, addonDirName, m.Plugins[pluginKey])
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, found...)
+	}
+	for _, pluginKey := range lockedDependencyKeys(m, lock) {
+		addonDirName, err := addonDirNameForPluginKey(pluginKey)
+		if err != nil {
+			continue
+		}
+		owned[addonDirName] = pluginKey
+		if _, err := os.Lstat(filepath.Join(addonsDir, addonDirName)); os.IsNotExist(err) {
+			findings = append(findings, doctorFinding{
+				problem: fmt.Sprintf("dependency %s is not installed (addons/%s is missing)", pluginKey, addonDirName),
+				hint:    "run `gdpm install`",
+			})
+		}
+	}
+
+	entries, err := os.ReadDir(addonsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if _, ok := owned[name]; ok {
+			continue
+		}
+		entryPath := filepath.Join(addonsDir, name)
+		if entry.Type()&os.ModeSymlink != 0 {
+			if _, err := os.Stat(entryPath); err != nil {
+				target, _ := os.Readlink(entryPath)
+				findings = append(findings, doctorFinding{
+					problem: fmt.Sprintf("addons/%s is a broken symlink to %s", name, target),
+					fixDesc: "delete the symlink",
+					fix: func(tx *transaction) error {
+						return tx.removeAddon(entryPath)
+					},
+				})
+				continue
+			}
+		}
+		if addonDirNameRe.MatchString(name) {
+			findings = append(findings, doctorFinding{
+				problem: fmt.Sprintf("addons/%s is not in gdpm.json and no plugin depends on it", name),
+				hint:    "run `gdpm prune` to delete it, or add the plugin to gdpm.json",
+			})
+		}
+	}
+
+	projectGodotPath := filepath.Join(projectDir, "project.godot")
+	if _, err := os.Stat(projectGodotPath); err != nil {
+		if os.IsNotExist(err) {
+			return findings, nil
+		}
+		return nil, err
+	}
+	enabled, err := project.EnabledEditorPlugins(projectGodotPath)
+	if err != nil {
+		findings = append(findings, doctorFinding{
+			problem: fmt.Sprintf("project.godot has an [editor_plugins] list gdpm cannot read: %v", err),
+			hint:    "fix it in Godot's Project Settings > Plugins",
+		})
+		return findings, nil
+	}
+	enabledSet := map[string]bool{}
+	for _, resPath := range enabled {
+		enabledSet[resPath] = true
+		if !strings.HasPrefix(resPath, "res://") {
+			continue
+		}
+		p := filepath.Join(projectDir, filepath.FromSlash(strings.TrimPrefix(resPath, "res://")))
+		if _, err := os.Stat(p); err == nil || !os.IsNotExist(err) {
+			continue
+		}
+		findings = append(findings, doctorFinding{
+			problem: fmt.Sprintf("project.godot enables %s, which does not exist", resPath),
+			fixDesc: "disable it",
+			fix: func(*transaction) error {
+				_, err := project.SetEditorPluginEnabled(projectGodotPath, resPath, false)
+				return err
+			},
+		})
+	}
+
+	for _, pluginKey := range sortedKeys(m.Plugins) {
+		plugin := m.Plugins[pluginKey]
+		if !pluginLinkEnabled(plugin) {
+			continue
+		}
+		addonDirName, err := addonDirNameForPluginKey(pluginKey)
+		if err != nil {
+			continue
+		}
+		target, err := pluginAbsPath(projectDir, pluginLinkPath(plugin))
+		if err != nil || target == "" {
+			continue
+		}
+		resolvedTarget, err := filepath.EvalSymlinks(target)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if name == addonDirName || !enabledSet["res://"+path.Join("addons", name, "plugin.cfg")] {
+				continue
+			}
+			resolved, err := filepath.EvalSymlinks(filepath.Join(addonsDir, name))
+			if err != nil || filepath.Clean(resolved) != filepath.Clean(resolvedTarget) {
+				continue
+			}
+			findings = append(findings, doctorFinding{
+				problem: fmt.Sprintf("addons/%s points at the same checkout as linked %s and is still enabled in project.godot", name, pluginKey),
+				fixDesc: fmt.Sprintf("disable it and move its autoloads to addons/%s", addonDirName),
+				fix: func(*transaction) error {
+					return disableEditorPluginAliases(projectGodotPath, projectDir, m, pluginKey, addonDirName, target)
+				},
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+func checkInstalledPlugin(projectDir, pluginKey, addonDirName string, plugin manifest.Plugin) ([]doctorFinding, error) {
+	dst := filepath.Join(projectDir, "addons", addonDirName)
+	info, lstatErr := os.Lstat(dst)
+	if lstatErr != nil && !os.IsNotExist(lstatErr) {
+		return nil, lstatErr
+	}
+
+	if pluginLinkEnabled(plugin) {
+		target, err := pluginAbsPath(projectDir, pluginLinkPath(plugin))
+		if err != nil || target == "" {
+			return []doctorFinding{{
+				problem: fmt.Sprintf("%s is linked without a local path", pluginKey),
+				hint:    fmt.Sprintf("run `gdpm link %s <local_path>` or `gdpm unlink %s`", pluginKey, pluginKey),
+			}}, nil
+		}
+		if ok, err := pluginCfgExistsAtDirRoot(target); err != nil || !ok {
+			return []doctorFinding{{
+				problem: fmt.Sprintf("%s is linked to %s, which has no plugin.cfg (was the checkout moved or deleted?)", pluginKey, pluginLinkPath(plugin)),
+				hint:    fmt.Sprintf("run `gdpm link %s <new_path>` or `gdpm unlink %s`", pluginKey, pluginKey),
+			}}, nil
+		}
+		resolvedTarget, _ := filepath.EvalSymlinks(target)
+		resolved, err := filepath.EvalSymlinks(dst)
+		if lstatErr != nil || err != nil || filepath.Clean(resolved) != filepath.Clean(resolvedTarget) {
+			return []doctorFinding{{
+				problem: fmt.Sprintf("addons/%s does not point at the linked checkout of %s", addonDirName, pluginKey),
+				hint:    fmt.Sprintf("run `gdpm link %s`", pluginKey),
+			}}, nil
+		}
+		return nil, nil
+	}
+
+	if lstatErr != nil {
+		return []doctorFinding{{
+			problem: fmt.Sprintf("%s is not installed (addons/%s is missing)", pluginKey, addonDirName),
+			hint:    "run `gdpm install`",
+		}}, nil
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if _, err := os.Stat(dst); err == nil {
+			return nil, nil
+		}
+		target, _ := os.Readlink(dst)
+		if strings.TrimSpace(plugin.Path) != "" {
+			return []doctorFinding{{
+				problem: fmt.Sprintf("addons/%s points at %s, which no longer exists", addonDirName, target),
+				hint:    fmt.Sprintf("restore %s or remove %s from gdpm.json", plugin.Path, pluginKey),
+			}}, nil
+		}
+		return []doctorFinding{{
+			problem: fmt.Sprintf("addons/%s is a broken symlink to %s", addonDirName, target),
+			fixDesc: "delete the symlink (then run `gdpm install`)",
+			fix: func(tx *transaction) error {
+				return tx.removeAddon(dst)
+			},
+		}}, nil
+	}
+	if ok, err := pluginCfgExistsAtDirRoot(dst); err != nil || !ok {
+		return []doctorFinding{{
+			problem: fmt.Sprintf("addons/%s has no plugin.cfg", addonDirName),
+			hint:    "run `gdpm install --repair`",
+		}}, nil
+	}
+	return nil, nil
+}
+
+func stagingDirEmpty(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.Name() != ".gdignore" {
+			return false
+		}
+	}
+	return true
+}
+
+func removeLinkEntry(linkPath, pluginKey string) error {
+	lm, err := manifest.LoadLinkManifest(linkPath)
+	if err != nil {
+		return err
+	}
+	delete(lm.Plugins, pluginKey)
+	if len(lm.Plugins) == 0 {
+		return removeIfExists(linkPath)
+	}
+	return manifest.SaveLinkManifest(linkPath, lm)
+}
+
+func removeIfExists(p string) error {
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+)
+
+func TestDoctor_ReportsAndFixesSafeProblems(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping on Windows (symlink/junction behavior varies by environment)")
+	}
+
+	projectDir := t.TempDir()
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@user/ui", manifest.Plugin{})
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+	// A lock entry that no plugin in gdpm.json needs does not own its addon.
+	lock := manifest.NewLock()
+	lock.Plugins["@user/stray"] = manifest.LockedPlugin{Owner: "user", Repo: "stray", SHA: "abc", Version: "1.0.0"}
+	if err := manifest.SaveLock(filepath.Join(projectDir, manifest.LockFilename), lock); err != nil {
+		t.Fatalf("write gdpm.lock: %v", err)
+	}
+	if err := manifest.SaveLinkManifest(filepath.Join(projectDir, manifest.LinkFilename), manifest.LinkManifest{
+		Plugins: map[string]manifest.Link{"@user/gone": {Enabled: true, Path: "../gone"}},
+	}); err != nil {
+		t.Fatalf("write link file: %v", err)
+	}
+
+	addonsDir := filepath.Join(projectDir, "addons")
+	for _, dir := range []string{"@user_ui", "@user_stray"} {
+		if err := os.MkdirAll(filepath.Join(addonsDir, dir), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(addonsDir, dir, "plugin.cfg"), []byte("[plugin]\n"), 0o644); err != nil {
+			t.Fatalf("write plugin.cfg: %v", err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(projectDir, "deleted_checkout"), filepath.Join(addonsDir, "@user_broken")); err != nil {
+		t.Fatalf("symlink: %v", err)
+	}
+
+	projectGodotPath := filepath.Join(projectDir, "project.godot")
+	projectGodot := "config_version=5\n\n[editor_plugins]\nenabled=PackedStringArray(\"res://addons/@user_ui/plugin.cfg\", \"res://addons/@user_missing/plugin.cfg\")\n"
+	if err := os.WriteFile(projectGodotPath, []byte(projectGodot), 0o644); err != nil {
+		t.Fatalf("write project.godot: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	findings, err := checkProject(projectDir)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	var problems []string
+	for _, f := range findings {
+		problems = append(problems, f.problem)
+	}
+	report := strings.Join(problems, "\n")
+	for _, want := range []string{
+		manifest.LinkFilename + " has an entry for @user/gone",
+		"addons/@user_broken is a broken symlink",
+		"addons/@user_stray is not in gdpm.json",
+		"project.godot enables res://addons/@user_missing/plugin.cfg",
+	} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("expected findings to mention %q, got:\n%s", want, report)
+		}
+	}
+	if len(findings) != 4 {
+		t.Fatalf("expected 4 findings, got:\n%s", report)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, manifest.LinkFilename)); err != nil {
+		t.Fatalf("expected doctor without --fix to leave files alone: %v", err)
+	}
+
+	if err := Doctor(context.Background(), DoctorOptions{Fix: true}); !errors.Is(err, ErrUnhealthy) {
+		t.Fatalf("expected unfixable stray addon to keep the project unhealthy, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, manifest.LinkFilename)); !os.IsNotExist(err) {
+		t.Fatalf("expected orphaned link file to be removed, got err=%v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(addonsDir, "@user_broken")); !os.IsNotExist(err) {
+		t.Fatalf("expected broken symlink to be removed, got err=%v", err)
+	}
+	data, err := os.ReadFile(projectGodotPath)
+	if err != nil {
+		t.Fatalf("read project.godot: %v", err)
+	}
+	if strings.Contains(string(data), "@user_missing") || !strings.Contains(string(data), "res://addons/@user_ui/plugin.cfg") {
+		t.Fatalf("expected only the missing plugin to be disabled, got:\n%s", data)
+	}
+
+	if err := os.RemoveAll(filepath.Join(addonsDir, "@user_stray")); err != nil {
+		t.Fatalf("remove stray: %v", err)
+	}
+	if err := Doctor(context.Background(), DoctorOptions{}); err != nil {
+		t.Fatalf("expected a healthy project, got: %v", err)
+	}
+	if dirs := stagingDirs(t, projectDir); len(dirs) != 0 {
+		t.Fatalf("expected no staging directories to be left, got %v", dirs)
+	}
+}
@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+)
+
+type installMode struct {
+	frozen bool
+	repair bool
+	force  bool
+}
+
+func checkInstalledTree(ctx context.Context, fetcher *packageFetcher, mode installMode, pluginKey, addonDirName, dst string, locked *manifest.LockedPlugin) (bool, error) {
+	if mode.force {
+		return true, nil
+	}
+
+	if locked.Tree != "" {
+		// Without the package at hand, editor files can only be left out
+		// wholesale; that matches the lock when the package ships none.
+		treeHash, err := fsutil.HashInstalledTree(dst, "")
+		if err != nil {
+			return false, err
+		}
+		if treeHash == locked.Tree {
+			return false, nil
+		}
+	} else if mode.frozen {
+		return false, fmt.Errorf("%w: %s has no tree hash for %s (run `gdpm install` without --frozen)", ErrUserInput, manifest.LockFilename, pluginKey)
+	}
+
+	// The baseline is the package at the pinned SHA, never what happens to
+	// be on disk, so local edits are not accepted as the pinned content.
+	tmpDir, err := os.MkdirTemp("", "gdpm-drift-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	baseline := locked.Tree
+	pinnedDir := ""
+	pinned, fetchErr := fetcher.fetchLockedPackage(ctx, tmpDir, *locked, addonDirName)
+	if fetchErr == nil {
+		pinnedDir = pinned.rootDir
+		if baseline == "" {
+			if baseline, err = fsutil.HashTree(pinnedDir); err != nil {
+				return false, err
+			}
+			locked.Tree = baseline
+		}
+	} else if baseline == "" {
+		fmt.Printf("%s: cannot check addons/%s: %s has no tree hash and the pinned package could not be fetched: %v\n", pluginKey, addonDirName, manifest.LockFilename, fetchErr)
+		return false, nil
+	}
+
+	treeHash, err := fsutil.HashInstalledTree(dst, pinnedDir)
+	if err != nil {
+		return false, err
+	}
+	if treeHash == baseline {
+		return false, nil
+	}
+	if mode.frozen && !mode.repair {
+		return false, fmt.Errorf("%w: %s does not match the tree hash in %s (%s); run `gdpm install --frozen --repair` to restore it", ErrUserInput, dst, manifest.LockFilename, pluginKey)
+	}
+
+	fmt.Printf("%s: addons/%s has local changes (pinned %s)\n", pluginKey, addonDirName, shortSHA(locked.SHA))
+	if fetchErr != nil {
+		fmt.Printf("  could not list changed files: %v\n", fetchErr)
+	} else if err := reportDrift(pinned.rootDir, dst); err != nil {
+		fmt.Printf("  could not list changed files: %v\n", err)
+	}
+	if !mode.repair {
+		fmt.Println("  run `gdpm install --repair` to restore it")
+	}
+	return mode.repair, nil
+}
+
+func reportDrift(pinnedDir, dst string) error {
+	diff, err := fsutil.DiffTrees(pinnedDir, dst)
+	if err != nil {
+		return err
+	}
+	for _, rel := range diff.Modified {
+		fmt.Printf("  modified  %s\n", rel)
+	}
+	for _, rel := range diff.Missing {
+		fmt.Printf("  missing   %s\n", rel)
+	}
+	for _, rel := range diff.Extra {
+		fmt.Printf("  extra     %s\n", rel)
+	}
+	return nil
+}
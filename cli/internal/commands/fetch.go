@@ -0,0 +1,289 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aviorstudio/gdpm/cli/internal/cache"
+	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+	"github.com/aviorstudio/gdpm/cli/internal/gitcli"
+	"github.com/aviorstudio/gdpm/cli/internal/giteaapi"
+	"github.com/aviorstudio/gdpm/cli/internal/githubapi"
+	"github.com/aviorstudio/gdpm/cli/internal/gitlabapi"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+)
+
+type fetchedPackage struct {
+	rootDir       string
+	zipballDigest string
+}
+
+type packageFetcher struct {
+	gh      *githubapi.Client
+	cache   *cache.Cache
+	offline bool
+}
+
+func newPackageFetcher(offline bool) (*packageFetcher, error) {
+	c, err := cache.Open()
+	if err != nil {
+		if offline {
+			return nil, fmt.Errorf("%w: %v", ErrUserInput, err)
+		}
+		// The cache only saves downloads; without it everything is fetched
+		// directly instead.
+		fmt.Fprintf(os.Stderr, "warning: download cache unavailable, downloading without it: %v\n", err)
+	}
+	return &packageFetcher{
+		gh:      githubapi.NewClient(os.Getenv("GITHUB_TOKEN")),
+		cache:   c,
+		offline: offline,
+	}, nil
+}
+
+func offlineMode(offline bool) bool {
+	if offline {
+		return true
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(os.Getenv("GDPM_OFFLINE")))
+	return err == nil && enabled
+}
+
+func (f *packageFetcher) missingArtifact(source manifest.LockedPlugin) bool {
+	owner, repo := cacheKey(source)
+	return f.cache == nil || !cache.Cacheable(source.SHA) || !f.cache.HasZipball(owner, repo, source.SHA)
+}
+
+// gitCacheOwner holds cached git checkouts. "+" cannot appear in a GitHub
+// owner name, so it never collides with a zipball.
+const gitCacheOwner = "git+"
+
+func cacheKey(source manifest.LockedPlugin) (string, string) {
+	if source.Git != "" {
+		return gitCacheOwner, url.QueryEscape(gitRemoteCacheKey(source.Git))
+	}
+	if gdpmdb.IsDefaultGitHubHost(source.Host) {
+		return source.Owner, source.Repo
+	}
+	return source.Host, url.PathEscape(source.Owner + "/" + source.Repo)
+}
+
+// gitRemoteCacheKey drops credentials from a remote URL so they are not
+// written into the cache directory names.
+func gitRemoteCacheKey(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.Scheme == "" || u.User == nil {
+		return remote
+	}
+	u.User = nil
+	return u.String()
+}
+
+func archiveLabel(source manifest.LockedPlugin) string {
+	if source.Git != "" {
+		return gitRemoteCacheKey(source.Git)
+	}
+	label := source.Owner + "/" + source.Repo
+	if !gdpmdb.IsDefaultGitHubHost(source.Host) {
+		label = source.Host + "/" + label
+	}
+	return label
+}
+
+func (f *packageFetcher) fetchLockedPackage(ctx context.Context, workDir string, source manifest.LockedPlugin, addonDirName string) (fetchedPackage, error) {
+	if source.Git != "" {
+		return f.fetchGitPackage(ctx, workDir, source, addonDirName)
+	}
+	return f.fetchArchivePackage(ctx, workDir, source, addonDirName)
+}
+
+// fetchGitPackage checks out a git source. The checkout is kept in the
+// download cache as a zip keyed by remote and commit, so it can be installed
+// again offline. The zip is built locally and not reproducible, so git
+// sources record no zipball digest; the lock's tree hash covers them.
+func (f *packageFetcher) fetchGitPackage(ctx context.Context, workDir string, source manifest.LockedPlugin, addonDirName string) (fetchedPackage, error) {
+	zipPath := filepath.Join(workDir, "repo.zip")
+	owner, repo := cacheKey(source)
+	cacheable := f.cache != nil && cache.Cacheable(source.SHA)
+	if cacheable {
+		if ok, err := f.cache.FetchZipball(owner, repo, source.SHA, zipPath); err != nil {
+			if f.offline {
+				return fetchedPackage{}, fmt.Errorf("read cache: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "warning: could not read %s@%s from the download cache: %v\n", archiveLabel(source), source.SHA, err)
+		} else if ok {
+			rootDir, err := fsutil.ExtractZip(zipPath, filepath.Join(workDir, "extract"))
+			if err != nil {
+				return fetchedPackage{}, err
+			}
+			return packageAtSubdir(rootDir, "", source.Subdir, addonDirName)
+		}
+	}
+	if f.offline {
+		return fetchedPackage{}, fmt.Errorf("%w: %s@%s is not in the download cache at %s (offline)", ErrUserInput, archiveLabel(source), source.SHA, f.cache.Dir())
+	}
+
+	checkoutDir := filepath.Join(workDir, "checkout")
+	if err := gitcli.FetchCommit(ctx, source.Git, source.SHA, checkoutDir); err != nil {
+		return fetchedPackage{}, err
+	}
+	if cacheable {
+		if err := f.storeGitCheckout(owner, repo, source.SHA, checkoutDir, zipPath); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not save %s@%s to the download cache: %v\n", archiveLabel(source), source.SHA, err)
+		}
+	}
+	return packageAtSubdir(checkoutDir, "", source.Subdir, addonDirName)
+}
+
+func (f *packageFetcher) storeGitCheckout(owner, repo, sha, checkoutDir, zipPath string) error {
+	if err := fsutil.CreateZip(checkoutDir, zipPath); err != nil {
+		return err
+	}
+	return f.cache.StoreZipball(owner, repo, sha, zipPath)
+}
+
+func (f *packageFetcher) fetchArchivePackage(ctx context.Context, workDir string, source manifest.LockedPlugin, addonDirName string) (fetchedPackage, error) {
+	zipPath := filepath.Join(workDir, "repo.zip")
+	if err := f.downloadZipball(ctx, source, zipPath); err != nil {
+		return fetchedPackage{}, err
+	}
+	zipballDigest, err := fsutil.HashFile(zipPath)
+	if err != nil {
+		return fetchedPackage{}, err
+	}
+
+	extractDir := filepath.Join(workDir, "extract")
+	rootDir, err := fsutil.ExtractZip(zipPath, extractDir)
+	if err != nil {
+		return fetchedPackage{}, err
+	}
+	return packageAtSubdir(rootDir, zipballDigest, source.Subdir, addonDirName)
+}
+
+func packageAtSubdir(rootDir, zipballDigest, repoSubdir, addonDirName string) (fetchedPackage, error) {
+	pkgRootDir, err := repoSubdirRoot(rootDir, repoSubdir)
+	if err != nil {
+		return fetchedPackage{}, fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+
+	if ok, err := pluginCfgExistsAtDirRoot(pkgRootDir); err != nil {
+		return fetchedPackage{}, fmt.Errorf("%w: %v", ErrUserInput, err)
+	} else if !ok {
+		expected := "res://" + path.Join("addons", addonDirName, "plugin.cfg")
+		if strings.TrimSpace(repoSubdir) != "" {
+			return fetchedPackage{}, fmt.Errorf("%w: package is missing plugin.cfg at %s in repository (expected to install it to %s)", ErrUserInput, repoSubdir, expected)
+		}
+		return fetchedPackage{}, fmt.Errorf("%w: package is missing plugin.cfg at repository root (expected to install it to %s)", ErrUserInput, expected)
+	}
+
+	return fetchedPackage{
+		rootDir:       pkgRootDir,
+		zipballDigest: zipballDigest,
+	}, nil
+}
+
+func (f *packageFetcher) downloadZipball(ctx context.Context, source manifest.LockedPlugin, zipPath string) error {
+	ref := source.SHA
+	if !cache.Cacheable(ref) {
+		if f.offline {
+			return fmt.Errorf("%w: cannot fetch %s@%s offline (not pinned to a commit SHA)", ErrUserInput, archiveLabel(source), ref)
+		}
+		return f.downloadArchive(ctx, source, zipPath)
+	}
+
+	if f.cache == nil {
+		return f.downloadArchive(ctx, source, zipPath)
+	}
+
+	owner, repo := cacheKey(source)
+	if ok, err := f.cache.FetchZipball(owner, repo, ref, zipPath); err != nil {
+		if f.offline {
+			return fmt.Errorf("read cache: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "warning: could not read %s@%s from the download cache: %v\n", archiveLabel(source), ref, err)
+	} else if ok {
+		return nil
+	}
+	if f.offline {
+		return fmt.Errorf("%w: %s@%s is not in the download cache at %s (offline)", ErrUserInput, archiveLabel(source), ref, f.cache.Dir())
+	}
+
+	if err := f.downloadArchive(ctx, source, zipPath); err != nil {
+		return err
+	}
+	if err := f.cache.StoreZipball(owner, repo, ref, zipPath); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not save %s@%s to the download cache: %v\n", archiveLabel(source), ref, err)
+	}
+	return nil
+}
+
+func (f *packageFetcher) downloadArchive(ctx context.Context, source manifest.LockedPlugin, zipPath string) error {
+	switch gdpmdb.ForgeForHost(source.Host) {
+	case gdpmdb.ForgeGitHub:
+		gh, err := f.githubClient(source.Host)
+		if err != nil {
+			return err
+		}
+		return gh.DownloadZipball(ctx, source.Owner, source.Repo, source.SHA, zipPath)
+	case gdpmdb.ForgeGitLab:
+		gl, err := newGitLabClient(source.Host)
+		if err != nil {
+			return err
+		}
+		return gl.DownloadArchive(ctx, source.Owner+"/"+source.Repo, source.SHA, zipPath)
+	case gdpmdb.ForgeGitea:
+		gt, err := newGiteaClient(source.Host)
+		if err != nil {
+			return err
+		}
+		return gt.DownloadArchive(ctx, source.Owner, source.Repo, source.SHA, zipPath)
+	}
+	return fmt.Errorf("%w: unsupported repo host %s", ErrUserInput, source.Host)
+}
+
+func (f *packageFetcher) githubClient(host string) (*githubapi.Client, error) {
+	if gdpmdb.IsDefaultGitHubHost(host) {
+		return f.gh, nil
+	}
+	return newGitHubClient(host)
+}
+
+func newGitHubClient(host string) (*githubapi.Client, error) {
+	if gdpmdb.IsDefaultGitHubHost(host) {
+		return githubapi.NewClient(os.Getenv("GITHUB_TOKEN")), nil
+	}
+	cfg, ok, err := gdpmdb.GitHubHost(host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a configured GitHub Enterprise host (add it to %s)", ErrUserInput, host, gdpmdb.GitHubHostsEnv)
+	}
+	if err := cfg.CheckTokenTransport(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	return githubapi.NewEnterpriseClient(cfg.APIBaseURL(), cfg.AuthToken()), nil
+}
+
+func newGitLabClient(host string) (*gitlabapi.Client, error) {
+	token, err := gdpmdb.ForgeToken(host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	return gitlabapi.NewClient("https://"+host, token), nil
+}
+
+func newGiteaClient(host string) (*giteaapi.Client, error) {
+	token, err := gdpmdb.ForgeToken(host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	return giteaapi.NewClient("https://"+host, token), nil
+}
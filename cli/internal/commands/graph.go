@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+)
+
+type graphNode struct {
+	pluginKey string
+	direct    bool
+	linked    bool
+	installed bool
+	version   string
+	sha       string
+	deps      map[string]string
+}
+
+type dependencyGraph struct {
+	roots []string
+	nodes map[string]*graphNode
+}
+
+func loadDependencyGraph(projectDir string) (dependencyGraph, error) {
+	m, err := manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		return dependencyGraph{}, err
+	}
+	lock, _, err := loadProjectLock(projectDir)
+	if err != nil {
+		return dependencyGraph{}, err
+	}
+
+	g := dependencyGraph{nodes: map[string]*graphNode{}}
+	for key := range m.Plugins {
+		g.roots = append(g.roots, key)
+	}
+	sort.Strings(g.roots)
+
+	queue := append([]string{}, g.roots...)
+	for len(queue) != 0 {
+		pluginKey := queue[0]
+		queue = queue[1:]
+		if _, ok := g.nodes[pluginKey]; ok {
+			continue
+		}
+
+		node := &graphNode{pluginKey: pluginKey}
+		locked, hasLock := lock.Plugins[pluginKey]
+		if plugin, ok := m.Plugins[pluginKey]; ok {
+			node.direct = true
+			node.linked = pluginLinkEnabled(plugin)
+			node.version = resolvedPluginVersion(lock, pluginKey, plugin)
+			if source, ok, err := lockedSourceForPlugin(plugin); err == nil && ok {
+				node.sha = source.SHA
+			}
+		} else if hasLock {
+			node.version = locked.Version
+			node.sha = locked.SHA
+		}
+
+		addonDirName, err := addonDirNameForPluginKey(pluginKey)
+		if err != nil {
+			return dependencyGraph{}, fmt.Errorf("%w: invalid plugin key: %s (%v)", ErrUserInput, pluginKey, err)
+		}
+		dir := filepath.Join(projectDir, "addons", addonDirName)
+		if _, err := os.Stat(dir); err == nil {
+			node.installed = true
+			deps, err := manifest.LoadDependencies(dir)
+			if err != nil {
+				return dependencyGraph{}, fmt.Errorf("%w: %v", ErrUserInput, err)
+			}
+			node.deps = deps
+		} else if os.IsNotExist(err) {
+			node.deps = locked.Dependencies
+		} else {
+			return dependencyGraph{}, err
+		}
+
+		g.nodes[pluginKey] = node
+		for _, depKey := range sortedDependencyKeys(node.deps) {
+			queue = append(queue, depKey)
+		}
+	}
+	return g, nil
+}
+
+func (n *graphNode) label() string {
+	parts := []string{n.pluginKey, displayVersion(n.version)}
+	if sha := shortSHA(n.sha); sha != "" {
+		parts = append(parts, sha)
+	}
+
+	kind := "transitive"
+	if n.direct {
+		kind = "direct"
+	}
+	state := "missing"
+	switch {
+	case n.linked:
+		state = "linked"
+	case n.installed:
+		state = "installed"
+	}
+	return strings.Join(parts, " ") + " (" + kind + ", " + state + ")"
+}
+
+func renderDependencyTree(w io.Writer, g dependencyGraph) error {
+	printed := map[string]bool{}
+
+	var walk func(pluginKey, prefix string, ancestors map[string]bool) error
+	walk = func(pluginKey, prefix string, ancestors map[string]bool) error {
+		depKeys := sortedDependencyKeys(g.nodes[pluginKey].deps)
+		for i, depKey := range depKeys {
+			branch, indent := "├── ", "│   "
+			if i == len(depKeys)-1 {
+				branch, indent = "└── ", "    "
+			}
+
+			node := g.nodes[depKey]
+			switch {
+			case ancestors[depKey]:
+				if _, err := fmt.Fprintf(w, "%s%s%s (cycle)\n", prefix, branch, depKey); err != nil {
+					return err
+				}
+				continue
+			case printed[depKey] && len(node.deps) != 0:
+				if _, err := fmt.Fprintf(w, "%s%s%s (deduped)\n", prefix, branch, node.label()); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "%s%s%s\n", prefix, branch, node.label()); err != nil {
+				return err
+			}
+			printed[depKey] = true
+			ancestors[depKey] = true
+			if err := walk(depKey, prefix+indent, ancestors); err != nil {
+				return err
+			}
+			delete(ancestors, depKey)
+		}
+		return nil
+	}
+
+	for _, pluginKey := range g.roots {
+		if _, err := fmt.Fprintln(w, g.nodes[pluginKey].label()); err != nil {
+			return err
+		}
+		printed[pluginKey] = true
+		if err := walk(pluginKey, "", map[string]bool{pluginKey: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dependencyChains(g dependencyGraph, target string) [][]string {
+	var chains [][]string
+
+	var walk func(path []string)
+	walk = func(path []string) {
+		last := path[len(path)-1]
+		if last == target {
+			chains = append(chains, append([]string{}, path...))
+			return
+		}
+		for _, depKey := range sortedDependencyKeys(g.nodes[last].deps) {
+			if containsString(path, depKey) {
+				continue
+			}
+			walk(append(path, depKey))
+		}
+	}
+
+	for _, pluginKey := range g.roots {
+		walk([]string{pluginKey})
+	}
+	return chains
+}
+
+func sortedDependencyKeys(deps map[string]string) []string {
+	keys := make([]string, 0, len(deps))
+	for key := range deps {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+)
+
+func TestDependencyGraph_TreeAndChains(t *testing.T) {
+	projectDir := t.TempDir()
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@a/ui", manifest.Plugin{})
+	m = manifest.UpsertPlugin(m, "@b/game", manifest.Plugin{})
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+	writeTestAddon(t, projectDir, "@a/ui", `{"plugins":{"@c/tween":{"version":"^1.0.0"}}}`)
+	writeTestAddon(t, projectDir, "@b/game", `{"plugins":{"@a/ui":{},"@d/fx":{"version":"^2.0.0"}}}`)
+	writeTestAddon(t, projectDir, "@d/fx", `{"plugins":{"@c/tween":{"version":"^1.2.0"}}}`)
+
+	lock := manifest.NewLock()
+	lock.Plugins["@c/tween"] = manifest.LockedPlugin{Owner: "c", Repo: "tween", SHA: "0123456789abcdef", Version: "1.3.0"}
+	lock.Plugins["@d/fx"] = manifest.LockedPlugin{Owner: "d", Repo: "fx", SHA: "fedcba9876543210", Version: "2.1.0"}
+	if err := manifest.SaveLock(filepath.Join(projectDir, manifest.LockFilename), lock); err != nil {
+		t.Fatalf("write gdpm.lock: %v", err)
+	}
+
+	g, err := loadDependencyGraph(projectDir)
+	if err != nil {
+		t.Fatalf("loadDependencyGraph: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := renderDependencyTree(&out, g); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	want := `@a/ui - (direct, installed)
+└── @c/tween 1.3.0 0123456 (transitive, missing)
+@b/game - (direct, installed)
+├── @a/ui - (direct, installed) (deduped)
+└── @d/fx 2.1.0 fedcba9 (transitive, installed)
+    └── @c/tween 1.3.0 0123456 (transitive, missing)
+`
+	if out.String() != want {
+		t.Fatalf("unexpected tree:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	chains := dependencyChains(g, "@c/tween")
+	wantChains := [][]string{
+		{"@a/ui", "@c/tween"},
+		{"@b/game", "@a/ui", "@c/tween"},
+		{"@b/game", "@d/fx", "@c/tween"},
+	}
+	if !reflect.DeepEqual(chains, wantChains) {
+		t.Fatalf("unexpected chains: %v", chains)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	if err := Why(context.Background(), WhyOptions{Spec: "c/tween"}); err != nil {
+		t.Fatalf("why without @: %v", err)
+	}
+}
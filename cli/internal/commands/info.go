@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+	"github.com/aviorstudio/gdpm/cli/internal/project"
+	"github.com/aviorstudio/gdpm/cli/internal/spec"
+)
+
+type InfoOptions struct {
+	Spec string
+}
+
+type projectPluginStatus struct {
+	status  string
+	version string
+	sha     string
+}
+
+func Info(ctx context.Context, opts InfoOptions) error {
+	specInput := strings.TrimSpace(opts.Spec)
+	if specInput != "" && !strings.HasPrefix(specInput, "@") {
+		specInput = "@" + specInput
+	}
+	pkg, err := spec.ParsePackageSpec(specInput)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	if pkg.Version != "" {
+		return fmt.Errorf("%w: info does not take a version (use @username/plugin)", ErrUserInput)
+	}
+
+	db, err := openRegistry()
+	if err != nil {
+		return err
+	}
+	info, err := db.PluginInfo(ctx, pkg.Owner, pkg.Repo)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+
+	status, err := projectStatusForPlugin(pkg.Name())
+	if err != nil {
+		return err
+	}
+
+	ownerType := info.OwnerType
+	if ownerType == "" {
+		ownerType = "unknown"
+	}
+	repoPath := info.Path
+	if repoPath == "" {
+		repoPath = "-"
+	}
+
+	fmt.Println(info.Name)
+	fmt.Printf("  repo:    %s\n", info.Repo)
+	fmt.Printf("  path:    %s\n", repoPath)
+	fmt.Printf("  owner:   @%s (%s)\n", info.Owner, ownerType)
+	fmt.Printf("  project: %s\n", status.String())
+
+	if len(info.Versions) == 0 {
+		fmt.Println("no published versions")
+		return nil
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tVersion\tSHA\tPublished\t")
+	for _, v := range info.Versions {
+		marker := ""
+		if status.matches(v) {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t\n", marker, v.String(), shortSHA(v.SHA), displayVersion(v.CreatedAt))
+	}
+	return w.Flush()
+}
+
+func projectStatusForPlugin(pluginKey string) (projectPluginStatus, error) {
+	startDir, err := os.Getwd()
+	if err != nil {
+		return projectPluginStatus{}, err
+	}
+	projectDir, ok := project.FindManifestDir(startDir)
+	if !ok {
+		return projectPluginStatus{status: "no gdpm.json found"}, nil
+	}
+
+	m, err := manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		return projectPluginStatus{}, err
+	}
+	lock, _, err := loadProjectLock(projectDir)
+	if err != nil {
+		return projectPluginStatus{}, err
+	}
+
+	plugin, direct := m.Plugins[pluginKey]
+	locked, inLock := lock.Plugins[pluginKey]
+	switch {
+	case direct && pluginLinkEnabled(plugin):
+		return projectPluginStatus{status: "linked to " + pluginLinkPath(plugin)}, nil
+	case direct && plugin.Path != "":
+		return projectPluginStatus{status: "installed from " + plugin.Path}, nil
+	case direct:
+		s := projectPluginStatus{
+			status:  "installed",
+			version: resolvedPluginVersion(lock, pluginKey, plugin),
+		}
+		if source, ok, err := lockedSourceForPlugin(plugin); err == nil && ok {
+			s.sha = source.SHA
+		}
+		addonDirName, err := addonDirNameForPluginKey(pluginKey)
+		if err != nil {
+			return projectPluginStatus{}, err
+		}
+		if _, err := os.Stat(filepath.Join(projectDir, "addons", addonDirName)); os.IsNotExist(err) {
+			s.status = "in gdpm.json, not installed"
+		}
+		return s, nil
+	case inLock:
+		return projectPluginStatus{status: "installed as a dependency", version: locked.Version, sha: locked.SHA}, nil
+	}
+	return projectPluginStatus{status: "not installed"}, nil
+}
+
+func (s projectPluginStatus) String() string {
+	if s.version == "" && s.sha == "" {
+		return s.status
+	}
+	return s.status + " " + versionAndSHA(s.version, s.sha)
+}
+
+func (s projectPluginStatus) matches(v gdpmdb.PluginVersion) bool {
+	if s.sha != "" {
+		return s.sha == v.SHA
+	}
+	return s.version != "" && s.version == v.String()
+}
@@ -0,0 +1,27 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestInfo_AcceptsSpecWithoutAt(t *testing.T) {
+	writeTestIndex(t, `"@studio/tween":{"repo":"https://github.com/studio/tween","versions":[{"version":"1.0.0","sha":"`+strings.Repeat("1", 40)+`"}]}`)
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Info(context.Background(), InfoOptions{Spec: "studio/tween"}); err != nil {
+		t.Fatalf("info without @: %v", err)
+	}
+}
@@ -2,21 +2,29 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
-	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
-	"github.com/aviorstudio/gdpm/cli/internal/githubapi"
 	"github.com/aviorstudio/gdpm/cli/internal/manifest"
 	"github.com/aviorstudio/gdpm/cli/internal/project"
 )
 
-type InstallOptions struct{}
+type InstallOptions struct {
+	Frozen  bool
+	Offline bool
+	Repair  bool
+	Force   bool
+	Prune   bool
+	Yes     bool
+	Jobs    int
+}
 
 type installCandidate struct {
 	pluginKey   string
@@ -25,14 +33,29 @@ type installCandidate struct {
 	version     string
 	ghOwner     string
 	ghRepo      string
+	host        string
+	gitRemote   string
 	ref         string
 	repoSubdir  string
 	prepRootDir string
+	replace     bool
+	// fetched is set when the package was already fetched while solving
+	// dependencies.
+	fetched *fetchedPackage
 }
 
-func Install(ctx context.Context, opts InstallOptions) error {
-	_ = opts
+func (c installCandidate) source() manifest.LockedPlugin {
+	return manifest.LockedPlugin{
+		Owner:  c.ghOwner,
+		Repo:   c.ghRepo,
+		Host:   c.host,
+		Git:    c.gitRemote,
+		Subdir: c.repoSubdir,
+		SHA:    c.ref,
+	}
+}
 
+func Install(ctx context.Context, opts InstallOptions) error {
 	startDir, err := os.Getwd()
 	if err != nil {
 		return err
@@ -43,12 +66,51 @@ func Install(ctx context.Context, opts InstallOptions) error {
 		return fmt.Errorf("%w: no gdpm.json found (run `gdpm init`)", ErrUserInput)
 	}
 
+	if opts.Prune && !opts.Yes && !stdinIsTerminal() {
+		return fmt.Errorf("%w: --prune needs --yes when stdin is not a terminal", ErrUserInput)
+	}
+
+	unlock, err := lockProject(ctx, projectDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	manifestPath := filepath.Join(projectDir, "gdpm.json")
 	m, err := manifest.Load(manifestPath)
 	if err != nil {
 		return err
 	}
 
+	lock, lockExists, err := loadProjectLock(projectDir)
+	if err != nil {
+		return err
+	}
+	tx, err := beginTransaction(projectDir)
+	if err != nil {
+		return err
+	}
+	defer tx.rollback()
+
+	fetcher, err := newPackageFetcher(offlineMode(opts.Offline))
+	if err != nil {
+		return err
+	}
+	if opts.Frozen {
+		if !lockExists {
+			return fmt.Errorf("%w: %s not found (run `gdpm install` without --frozen to create it)", ErrUserInput, manifest.LockFilename)
+		}
+		mismatched, err := lockMismatches(m, lock)
+		if err != nil {
+			return err
+		}
+		if len(mismatched) != 0 {
+			return fmt.Errorf("%w: %s does not match gdpm.json for: %s (run `gdpm install` without --frozen)", ErrUserInput, manifest.LockFilename, strings.Join(mismatched, ", "))
+		}
+	}
+
+	mode := installMode{frozen: opts.Frozen, repair: opts.Repair, force: opts.Force}
+
 	pluginKeys := make([]string, 0, len(m.Plugins))
 	for key := range m.Plugins {
 		pluginKeys = append(pluginKeys, key)
@@ -57,6 +119,8 @@ func Install(ctx context.Context, opts InstallOptions) error {
 
 	addonsDir := filepath.Join(projectDir, "addons")
 	candidates := make([]installCandidate, 0, len(pluginKeys))
+	nextLock := manifest.NewLock()
+	manifestChanged := false
 
 	for _, pluginKey := range pluginKeys {
 		addonDirName, err := addonDirNameForPluginKey(pluginKey)
@@ -68,45 +132,161 @@ func Install(ctx context.Context, opts InstallOptions) error {
 		}
 
 		plugin := m.Plugins[pluginKey]
+		locked, hasSource, err := lockedSourceForPlugin(plugin)
+		if err != nil {
+			return fmt.Errorf("%w: invalid repo for %s: %v", ErrUserInput, pluginKey, err)
+		}
+		if prev, ok := lock.Plugins[pluginKey]; ok && hasSource && prev.SameCommit(locked) {
+			locked.Zipball = prev.Zipball
+			locked.Tree = prev.Tree
+			locked.Dependencies = prev.Dependencies
+			if locked.Version == "" {
+				locked.Version = prev.Version
+			}
+		}
+
 		if pluginLinkEnabled(plugin) {
+			if hasSource {
+				nextLock.Plugins[pluginKey] = locked
+			}
+			continue
+		}
+		if pluginPath := strings.TrimSpace(plugin.Path); pluginPath != "" {
+			installed, err := installPathPlugin(tx, projectDir, addonDirName, pluginPath)
+			if err != nil {
+				return err
+			}
+			if installed {
+				fmt.Printf("installed %s (%s)\n", pluginKey, pluginPath)
+			}
 			continue
 		}
 
+		replace := false
+		// A lock entry pinned by SHA alone has no version to check, so it is
+		// re-resolved as soon as gdpm.json carries a constraint.
+		if hasSource && !opts.Frozen && !versionSatisfies(plugin.Version, locked.Version) {
+			pinned := pluginWithVersion(pluginKey, locked.Version)
+			if locked.Version == "" {
+				pinned = fmt.Sprintf("%s (%s, no version)", pluginKey, shortSHA(locked.SHA))
+			}
+			if fetcher.offline {
+				return fmt.Errorf("%w: %s does not satisfy %s in gdpm.json and cannot be re-resolved offline", ErrUserInput, pinned, plugin.Version)
+			}
+			if pluginFromRepo(plugin) {
+				return fmt.Errorf("%w: %s does not satisfy %s in gdpm.json (run `gdpm add` with its repository URL to re-pin it)", ErrUserInput, pinned, plugin.Version)
+			}
+			resolved, err := resolveRegistryPlugin(ctx, pluginKey, plugin.Version, false)
+			if err != nil {
+				return err
+			}
+			plugin.Repo = repoURLForResolved(resolved)
+			m = manifest.UpsertPlugin(m, pluginKey, plugin)
+			manifestChanged = true
+			locked = lockedSourceForResolved(resolved)
+			replace = true
+		}
+		if hasSource {
+			nextLock.Plugins[pluginKey] = locked
+		}
+
 		dst := filepath.Join(addonsDir, addonDirName)
-		if info, err := os.Lstat(dst); err == nil {
-			if info.IsDir() || info.Mode()&os.ModeSymlink != 0 {
+		if info, err := os.Lstat(dst); err == nil && !replace {
+			if info.Mode()&os.ModeSymlink != 0 {
 				continue
 			}
-			return fmt.Errorf("%w: addon path exists and is not a directory: %s", ErrUserInput, dst)
-		} else if !os.IsNotExist(err) {
+			if !info.IsDir() {
+				return fmt.Errorf("%w: addon path exists and is not a directory: %s", ErrUserInput, dst)
+			}
+			if !hasSource {
+				continue
+			}
+			reinstall, err := checkInstalledTree(ctx, fetcher, mode, pluginKey, addonDirName, dst, &locked)
+			if err != nil {
+				return err
+			}
+			nextLock.Plugins[pluginKey] = locked
+			if !reinstall {
+				continue
+			}
+			replace = true
+		} else if err != nil && !os.IsNotExist(err) {
 			return err
 		}
 
-		repoURL := strings.TrimSpace(plugin.Repo)
-		if repoURL == "" {
+		if !hasSource {
 			return fmt.Errorf("%w: plugin is not installed and has no repo: %s", ErrUserInput, pluginKey)
 		}
-		owner, repo, ref, repoSubdir, err := gdpmdb.ParseGitHubTreeURLWithPath(repoURL)
-		if err != nil {
-			return fmt.Errorf("%w: invalid repo for %s: %v", ErrUserInput, pluginKey, err)
+		if opts.Frozen && locked.Tree == "" {
+			return fmt.Errorf("%w: %s has no tree hash for %s (run `gdpm install` without --frozen)", ErrUserInput, manifest.LockFilename, pluginKey)
 		}
 
 		candidates = append(candidates, installCandidate{
 			pluginKey:  pluginKey,
 			addonDir:   addonDirName,
 			dst:        dst,
-			version:    strings.TrimSpace(plugin.Version),
-			ghOwner:    owner,
-			ghRepo:     repo,
-			ref:        ref,
-			repoSubdir: repoSubdir,
+			version:    locked.Version,
+			ghOwner:    locked.Owner,
+			ghRepo:     locked.Repo,
+			host:       locked.Host,
+			gitRemote:  locked.Git,
+			ref:        locked.SHA,
+			repoSubdir: locked.Subdir,
+			replace:    replace,
 		})
 	}
 
-	if len(candidates) == 0 {
-		return nil
+	if len(candidates) != 0 {
+		if err := installCandidates(ctx, tx, projectDir, fetcher, candidates, nextLock, opts.Frozen, opts.Jobs); err != nil {
+			return err
+		}
+	}
+
+	if err := resolveDependencies(ctx, tx, projectDir, fetcher, m, lock, nextLock, mode); err != nil {
+		return err
 	}
 
+	if opts.Prune {
+		orphans, err := orphanedAddonDirs(projectDir, m, nextLock)
+		if err != nil {
+			return err
+		}
+		if len(orphans) != 0 {
+			ok, err := confirmPrune(orphans, opts.Yes)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("nothing was pruned")
+				orphans = nil
+			}
+		}
+		if err := pruneAddonDirs(tx, projectDir, orphans); err != nil {
+			return err
+		}
+	}
+
+	if !opts.Frozen {
+		if manifestChanged {
+			if err := manifest.Save(manifestPath, m); err != nil {
+				return err
+			}
+		}
+		if err := saveProjectLockIfChanged(projectDir, nextLock, lock, lockExists); err != nil {
+			return err
+		}
+	}
+	return tx.commit()
+}
+
+type preparedCandidate struct {
+	fetched  fetchedPackage
+	treeHash string
+	err      error
+}
+
+func installCandidates(ctx context.Context, tx *transaction, projectDir string, fetcher *packageFetcher, candidates []installCandidate, lock manifest.Lock, frozen bool, jobs int) error {
+	addonsDir := filepath.Join(projectDir, "addons")
 	if err := os.MkdirAll(addonsDir, 0o755); err != nil {
 		return err
 	}
@@ -119,70 +299,100 @@ func Install(ctx context.Context, opts InstallOptions) error {
 		return err
 	}
 
-	tmpDir, err := os.MkdirTemp("", "gdpm-install-*")
-	if err != nil {
-		return err
-	}
-	defer os.RemoveAll(tmpDir)
-
-	gh := githubapi.NewClient(os.Getenv("GITHUB_TOKEN"))
-
+	pending := make([]int, 0, len(candidates))
 	for i := range candidates {
-		if _, err := os.Lstat(candidates[i].dst); err == nil {
+		if _, err := os.Lstat(candidates[i].dst); err == nil && !candidates[i].replace {
 			continue
-		} else if !os.IsNotExist(err) {
-			return err
-		}
-
-		pkgTmpDir := filepath.Join(tmpDir, fmt.Sprintf("pkg-%d", i))
-		if err := os.MkdirAll(pkgTmpDir, 0o755); err != nil {
+		} else if err != nil && !os.IsNotExist(err) {
 			return err
 		}
+		pending = append(pending, i)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
 
-		zipPath := filepath.Join(pkgTmpDir, "repo.zip")
-		if err := gh.DownloadZipball(ctx, candidates[i].ghOwner, candidates[i].ghRepo, candidates[i].ref, zipPath); err != nil {
-			return err
+	if fetcher.offline {
+		var missing []string
+		for _, i := range pending {
+			c := candidates[i]
+			if fetcher.missingArtifact(c.source()) {
+				missing = append(missing, fmt.Sprintf("  %s (%s@%s)", c.pluginKey, archiveLabel(c.source()), c.ref))
+			}
 		}
-
-		extractDir := filepath.Join(pkgTmpDir, "extract")
-		rootDir, err := fsutil.ExtractZip(zipPath, extractDir)
-		if err != nil {
-			return err
+		if len(missing) != 0 {
+			return fmt.Errorf("%w: offline and missing from the download cache at %s:\n%s", ErrUserInput, fetcher.cache.Dir(), strings.Join(missing, "\n"))
 		}
+	}
 
-		pkgRootDir, err := repoSubdirRoot(rootDir, candidates[i].repoSubdir)
-		if err != nil {
-			return fmt.Errorf("%w: %v", ErrUserInput, err)
-		}
+	tmpDir, err := os.MkdirTemp("", "gdpm-install-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
 
-		if ok, err := pluginCfgExistsAtDirRoot(pkgRootDir); err != nil {
-			return fmt.Errorf("%w: %v", ErrUserInput, err)
-		} else if !ok {
-			expected := "res://" + path.Join("addons", candidates[i].addonDir, "plugin.cfg")
-			if strings.TrimSpace(candidates[i].repoSubdir) != "" {
-				return fmt.Errorf("%w: package is missing plugin.cfg at %s in repository (expected to install it to %s)", ErrUserInput, candidates[i].repoSubdir, expected)
+	prepared := make([]preparedCandidate, len(candidates))
+	if jobs < 1 {
+		jobs = 1
+	}
+	if jobs > len(pending) {
+		jobs = len(pending)
+	}
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				pkgTmpDir := filepath.Join(tmpDir, fmt.Sprintf("pkg-%d", i))
+				prepared[i] = prepareCandidate(ctx, fetcher, pkgTmpDir, candidates[i], lock.Plugins[candidates[i].pluginKey], frozen)
 			}
-			return fmt.Errorf("%w: package is missing plugin.cfg at repository root (expected to install it to %s)", ErrUserInput, expected)
+		}()
+	}
+	for _, i := range pending {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	var errs []error
+	for _, i := range pending {
+		if prepared[i].err != nil {
+			errs = append(errs, fmt.Errorf("  %s: %w", candidates[i].pluginKey, prepared[i].err))
 		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("failed to install %d of %d plugins:\n%w", len(errs), len(pending), errors.Join(errs...))
+	}
 
-		if _, err := os.Lstat(candidates[i].dst); err == nil {
-			continue
-		} else if !os.IsNotExist(err) {
+	for _, i := range pending {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
+		if !candidates[i].replace {
+			if _, err := os.Lstat(candidates[i].dst); err == nil {
+				continue
+			} else if !os.IsNotExist(err) {
+				return err
+			}
+		}
 
-		if err := fsutil.CopyPath(pkgRootDir, candidates[i].dst); err != nil {
+		if err := tx.copyAddon(prepared[i].fetched.rootDir, candidates[i].dst); err != nil {
 			return err
 		}
 
 		if ok, err := pluginCfgExistsAtDirRoot(candidates[i].dst); err != nil {
-			_ = fsutil.RemoveAll(candidates[i].dst)
 			return fmt.Errorf("%w: %v", ErrUserInput, err)
 		} else if !ok {
-			_ = fsutil.RemoveAll(candidates[i].dst)
 			return fmt.Errorf("%w: installed addon is missing plugin.cfg at %s", ErrUserInput, filepath.Join(candidates[i].dst, "plugin.cfg"))
 		}
 
+		locked := lock.Plugins[candidates[i].pluginKey]
+		locked.Zipball = prepared[i].fetched.zipballDigest
+		locked.Tree = prepared[i].treeHash
+		lock.Plugins[candidates[i].pluginKey] = locked
+
 		if hasProjectGodot {
 			pluginCfgResPath := "res://" + path.Join("addons", candidates[i].addonDir, "plugin.cfg")
 			updated, err := project.SetEditorPluginEnabled(projectGodotPath, pluginCfgResPath, true)
@@ -203,3 +413,38 @@ func Install(ctx context.Context, opts InstallOptions) error {
 
 	return nil
 }
+
+func prepareCandidate(ctx context.Context, fetcher *packageFetcher, workDir string, candidate installCandidate, locked manifest.LockedPlugin, frozen bool) preparedCandidate {
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		return preparedCandidate{err: err}
+	}
+
+	var fetched fetchedPackage
+	if candidate.fetched != nil {
+		fetched = *candidate.fetched
+	} else {
+		var err error
+		fetched, err = fetcher.fetchLockedPackage(ctx, workDir, candidate.source(), candidate.addonDir)
+		if err != nil {
+			return preparedCandidate{err: err}
+		}
+	}
+
+	treeHash, err := fsutil.HashTree(fetched.rootDir)
+	if err != nil {
+		return preparedCandidate{err: err}
+	}
+	if frozen {
+		if locked.Zipball != "" && locked.Zipball != fetched.zipballDigest {
+			return preparedCandidate{err: fmt.Errorf("%w: downloaded zipball does not match %s (got %s, want %s)", ErrUserInput, manifest.LockFilename, fetched.zipballDigest, locked.Zipball)}
+		}
+		if locked.Tree != treeHash {
+			return preparedCandidate{err: fmt.Errorf("%w: downloaded tree does not match %s (got %s, want %s)", ErrUserInput, manifest.LockFilename, treeHash, locked.Tree)}
+		}
+	}
+
+	return preparedCandidate{
+		fetched:  fetched,
+		treeHash: treeHash,
+	}
+}
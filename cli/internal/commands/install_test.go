@@ -1,11 +1,16 @@
 package commands
 
 import (
+	"archive/zip"
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/aviorstudio/gdpm/cli/internal/cache"
+	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
 	"github.com/aviorstudio/gdpm/cli/internal/manifest"
 )
 
@@ -158,3 +163,528 @@ func TestInstall_DoesNotRemoveExistingAddonsWhenNoInstallsNeeded(t *testing.T) {
 		t.Fatalf("expected project.godot unchanged, got:\n%s", got)
 	}
 }
+
+func TestInstall_RecordsLockForExistingAddon(t *testing.T) {
+	projectDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv(cache.DirEnv, cacheDir)
+
+	sha := strings.Repeat("c", 40)
+	zipPath := filepath.Join(t.TempDir(), "repo.zip")
+	writeTestZipball(t, zipPath, "owner-repo-"+sha[:7], map[string]string{"addons/plugin/plugin.cfg": "[plugin]\n"})
+	if err := cache.New(cacheDir, 0).StoreZipball("owner", "repo", sha, zipPath); err != nil {
+		t.Fatalf("store zipball: %v", err)
+	}
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@user/plugin", manifest.Plugin{
+		Repo:    gdpmdb.GitHubTreeURLWithPath("owner", "repo", sha, "addons/plugin"),
+		Version: "1.2.3",
+	})
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	dst := filepath.Join(projectDir, "addons", "@user_plugin")
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		t.Fatalf("mkdir addons dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "plugin.cfg"), []byte("[plugin]\n"), 0o644); err != nil {
+		t.Fatalf("write plugin.cfg: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Install(context.Background(), InstallOptions{}); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+
+	lock, err := manifest.LoadLock(filepath.Join(projectDir, manifest.LockFilename))
+	if err != nil {
+		t.Fatalf("read gdpm.lock: %v", err)
+	}
+	locked, ok := lock.Plugins["@user/plugin"]
+	if !ok {
+		t.Fatalf("expected gdpm.lock entry for @user/plugin")
+	}
+	if locked.Owner != "owner" || locked.Repo != "repo" || locked.SHA != sha || locked.Subdir != "addons/plugin" || locked.Version != "1.2.3" {
+		t.Fatalf("unexpected lock entry: %#v", locked)
+	}
+	if locked.Tree == "" {
+		t.Fatalf("expected tree hash to be recorded")
+	}
+
+	if err := Install(context.Background(), InstallOptions{Frozen: true}); err != nil {
+		t.Fatalf("frozen install: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dst, "plugin.cfg"), []byte("[plugin]\nname=\"changed\"\n"), 0o644); err != nil {
+		t.Fatalf("modify plugin.cfg: %v", err)
+	}
+	if err := Install(context.Background(), InstallOptions{Frozen: true}); err == nil {
+		t.Fatalf("expected frozen install to fail on tree hash mismatch")
+	}
+}
+
+func TestInstall_FrozenErrorsWhenLockDisagreesWithManifest(t *testing.T) {
+	projectDir := t.TempDir()
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@user/plugin", manifest.Plugin{
+		Repo:    "https://github.com/owner/repo/tree/def456",
+		Version: "1.3.0",
+	})
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Install(context.Background(), InstallOptions{Frozen: true}); err == nil {
+		t.Fatalf("expected error when gdpm.lock is missing")
+	}
+
+	lock := manifest.NewLock()
+	lock.Plugins["@user/plugin"] = manifest.LockedPlugin{
+		Host:    "github.com",
+		Owner:   "owner",
+		Repo:    "repo",
+		SHA:     "abc123",
+		Version: "1.2.3",
+		Tree:    "sha256:aaa",
+	}
+	if err := manifest.SaveLock(filepath.Join(projectDir, manifest.LockFilename), lock); err != nil {
+		t.Fatalf("write gdpm.lock: %v", err)
+	}
+
+	err = Install(context.Background(), InstallOptions{Frozen: true})
+	if err == nil {
+		t.Fatalf("expected error when gdpm.lock disagrees with gdpm.json")
+	}
+	if !strings.Contains(err.Error(), "@user/plugin") {
+		t.Fatalf("expected error to mention @user/plugin, got: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(projectDir, "addons")); err == nil {
+		t.Fatalf("expected addons dir to not be created on error")
+	}
+}
+
+func TestInstall_FrozenAcceptsRangeSatisfiedByLock(t *testing.T) {
+	projectDir := t.TempDir()
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@user/plugin", manifest.Plugin{
+		Repo:    "https://github.com/owner/repo/tree/abc123",
+		Version: "^1.2",
+	})
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	dst := filepath.Join(projectDir, "addons", "@user_plugin")
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		t.Fatalf("mkdir addons dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "plugin.cfg"), []byte("[plugin]\n"), 0o644); err != nil {
+		t.Fatalf("write plugin.cfg: %v", err)
+	}
+	treeHash, err := fsutil.HashTree(dst)
+	if err != nil {
+		t.Fatalf("HashTree: %v", err)
+	}
+
+	lock := manifest.NewLock()
+	lock.Plugins["@user/plugin"] = manifest.LockedPlugin{
+		Host:    "github.com",
+		Owner:   "owner",
+		Repo:    "repo",
+		SHA:     "abc123",
+		Version: "1.4.0",
+		Tree:    treeHash,
+	}
+	lockPath := filepath.Join(projectDir, manifest.LockFilename)
+	if err := manifest.SaveLock(lockPath, lock); err != nil {
+		t.Fatalf("write gdpm.lock: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Install(context.Background(), InstallOptions{Frozen: true}); err != nil {
+		t.Fatalf("frozen install: %v", err)
+	}
+
+	lock.Plugins["@user/plugin"] = manifest.LockedPlugin{
+		Host:    "github.com",
+		Owner:   "owner",
+		Repo:    "repo",
+		SHA:     "abc123",
+		Version: "2.0.0",
+		Tree:    treeHash,
+	}
+	if err := manifest.SaveLock(lockPath, lock); err != nil {
+		t.Fatalf("write gdpm.lock: %v", err)
+	}
+	if err := Install(context.Background(), InstallOptions{Frozen: true}); err == nil {
+		t.Fatalf("expected frozen install to fail when locked version is outside the range")
+	}
+}
+
+func TestInstall_RechecksRangeForLockWithoutVersion(t *testing.T) {
+	projectDir := t.TempDir()
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@user/plugin", manifest.Plugin{
+		Repo:    "https://github.com/owner/repo/tree/abc123",
+		Version: "^1.2",
+	})
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	lock := manifest.NewLock()
+	lock.Plugins["@user/plugin"] = manifest.LockedPlugin{
+		Owner: "owner",
+		Repo:  "repo",
+		SHA:   "abc123",
+	}
+	if err := manifest.SaveLock(filepath.Join(projectDir, manifest.LockFilename), lock); err != nil {
+		t.Fatalf("write gdpm.lock: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	err = Install(context.Background(), InstallOptions{Offline: true})
+	if err == nil || !strings.Contains(err.Error(), "no version") || !strings.Contains(err.Error(), "^1.2") {
+		t.Fatalf("expected SHA-only lock entry to be checked against ^1.2, got: %v", err)
+	}
+}
+
+func writeTestZipball(t *testing.T, zipPath, rootDir string, files map[string]string) {
+	t.Helper()
+
+	f, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatalf("create zip: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(rootDir + "/" + name)
+		if err != nil {
+			t.Fatalf("zip create %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("zip write %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("close zip file: %v", err)
+	}
+}
+
+func TestInstall_OfflineInstallsFromDownloadCache(t *testing.T) {
+	projectDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv(cache.DirEnv, cacheDir)
+
+	cachedSHA := strings.Repeat("a", 40)
+	missingSHA := strings.Repeat("b", 40)
+
+	zipPath := filepath.Join(t.TempDir(), "repo.zip")
+	writeTestZipball(t, zipPath, "owner-cached-"+cachedSHA[:7], map[string]string{"plugin.cfg": "[plugin]\n"})
+	if err := cache.New(cacheDir, 0).StoreZipball("owner", "cached", cachedSHA, zipPath); err != nil {
+		t.Fatalf("store zipball: %v", err)
+	}
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@user/cached", manifest.Plugin{
+		Repo:    gdpmdb.GitHubTreeURLWithPath("owner", "cached", cachedSHA, ""),
+		Version: "1.0.0",
+	})
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Install(context.Background(), InstallOptions{Offline: true}); err != nil {
+		t.Fatalf("offline install: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "addons", "@user_cached", "plugin.cfg")); err != nil {
+		t.Fatalf("expected addon to be installed from cache: %v", err)
+	}
+
+	m = manifest.UpsertPlugin(m, "@user/missing", manifest.Plugin{
+		Repo:    gdpmdb.GitHubTreeURLWithPath("owner", "missing", missingSHA, ""),
+		Version: "1.0.0",
+	})
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	t.Setenv("GDPM_OFFLINE", "1")
+	err = Install(context.Background(), InstallOptions{})
+	if err == nil || !strings.Contains(err.Error(), "@user/missing (owner/missing@"+missingSHA+")") {
+		t.Fatalf("expected missing artifact error, got: %v", err)
+	}
+}
+
+func TestNewPackageFetcher_DownloadsWithoutUnusableCache(t *testing.T) {
+	t.Setenv(cache.MaxSizeEnv, "lots")
+
+	fetcher, err := newPackageFetcher(false)
+	if err != nil {
+		t.Fatalf("expected an unusable cache to fall back to direct downloads, got: %v", err)
+	}
+	if fetcher.cache != nil {
+		t.Fatalf("expected no cache, got %s", fetcher.cache.Dir())
+	}
+
+	if _, err := newPackageFetcher(true); err == nil {
+		t.Fatalf("expected offline mode to require the cache")
+	}
+}
+
+func TestInstall_RepairsDriftedAddon(t *testing.T) {
+	projectDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv(cache.DirEnv, cacheDir)
+
+	sha := strings.Repeat("c", 40)
+	zipPath := filepath.Join(t.TempDir(), "repo.zip")
+	writeTestZipball(t, zipPath, "owner-ui-"+sha[:7], map[string]string{
+		"plugin.cfg": "[plugin]\n",
+		"ui.gd":      "extends Node\n",
+	})
+	if err := cache.New(cacheDir, 0).StoreZipball("owner", "ui", sha, zipPath); err != nil {
+		t.Fatalf("store zipball: %v", err)
+	}
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@user/ui", manifest.Plugin{
+		Repo:    gdpmdb.GitHubTreeURLWithPath("owner", "ui", sha, ""),
+		Version: "1.0.0",
+	})
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Install(context.Background(), InstallOptions{Offline: true}); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+
+	addonDir := filepath.Join(projectDir, "addons", "@user_ui")
+	scriptPath := filepath.Join(addonDir, "ui.gd")
+	if err := os.WriteFile(scriptPath, []byte("extends Node2D\n"), 0o644); err != nil {
+		t.Fatalf("modify addon: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(addonDir, "notes.txt"), []byte("todo\n"), 0o644); err != nil {
+		t.Fatalf("add file: %v", err)
+	}
+
+	if err := Install(context.Background(), InstallOptions{Offline: true}); err != nil {
+		t.Fatalf("install without repair: %v", err)
+	}
+	if data, _ := os.ReadFile(scriptPath); string(data) != "extends Node2D\n" {
+		t.Fatalf("expected install without --repair to leave local changes, got %q", data)
+	}
+	if err := Install(context.Background(), InstallOptions{Offline: true, Frozen: true}); err == nil {
+		t.Fatalf("expected frozen install to reject the drifted addon")
+	}
+
+	if err := Install(context.Background(), InstallOptions{Offline: true, Repair: true}); err != nil {
+		t.Fatalf("install --repair: %v", err)
+	}
+	if data, _ := os.ReadFile(scriptPath); string(data) != "extends Node\n" {
+		t.Fatalf("expected --repair to restore ui.gd, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(addonDir, "notes.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected --repair to remove extra files, got err=%v", err)
+	}
+
+	if err := os.Remove(scriptPath); err != nil {
+		t.Fatalf("delete file: %v", err)
+	}
+	if err := Install(context.Background(), InstallOptions{Offline: true, Frozen: true, Repair: true}); err != nil {
+		t.Fatalf("install --frozen --repair: %v", err)
+	}
+	if _, err := os.Stat(scriptPath); err != nil {
+		t.Fatalf("expected --frozen --repair to restore the missing file: %v", err)
+	}
+}
+
+func TestInstall_IgnoresEditorFilesAndChecksPinnedPackage(t *testing.T) {
+	projectDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv(cache.DirEnv, cacheDir)
+
+	sha := strings.Repeat("d", 40)
+	zipPath := filepath.Join(t.TempDir(), "repo.zip")
+	writeTestZipball(t, zipPath, "owner-ui-"+sha[:7], map[string]string{
+		"plugin.cfg":     "[plugin]\n",
+		"ui.gd":          "extends Node\n",
+		"shipped.gd.uid": "uid://shipped\n",
+	})
+	if err := cache.New(cacheDir, 0).StoreZipball("owner", "ui", sha, zipPath); err != nil {
+		t.Fatalf("store zipball: %v", err)
+	}
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@user/ui", manifest.Plugin{
+		Repo:    gdpmdb.GitHubTreeURLWithPath("owner", "ui", sha, ""),
+		Version: "1.0.0",
+	})
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Install(context.Background(), InstallOptions{Offline: true}); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+
+	addonDir := filepath.Join(projectDir, "addons", "@user_ui")
+	scriptPath := filepath.Join(addonDir, "ui.gd")
+	uidPath := filepath.Join(addonDir, "ui.gd.uid")
+	if err := os.WriteFile(uidPath, []byte("uid://abc\n"), 0o644); err != nil {
+		t.Fatalf("write uid: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(addonDir, "icon.svg.import"), []byte("[remap]\n"), 0o644); err != nil {
+		t.Fatalf("write import: %v", err)
+	}
+	if err := Install(context.Background(), InstallOptions{Offline: true, Frozen: true}); err != nil {
+		t.Fatalf("expected editor files not to count as drift: %v", err)
+	}
+
+	// Editor files the package ships are checked like any other file.
+	shippedPath := filepath.Join(addonDir, "shipped.gd.uid")
+	if err := os.WriteFile(shippedPath, []byte("uid://edited\n"), 0o644); err != nil {
+		t.Fatalf("modify shipped uid: %v", err)
+	}
+	if err := Install(context.Background(), InstallOptions{Offline: true, Frozen: true}); err == nil {
+		t.Fatalf("expected frozen install to reject an edited shipped editor file")
+	}
+
+	if err := os.WriteFile(scriptPath, []byte("extends Node2D\n"), 0o644); err != nil {
+		t.Fatalf("modify addon: %v", err)
+	}
+	if err := Install(context.Background(), InstallOptions{Offline: true, Repair: true}); err != nil {
+		t.Fatalf("install --repair: %v", err)
+	}
+	if data, _ := os.ReadFile(scriptPath); string(data) != "extends Node\n" {
+		t.Fatalf("expected --repair to restore ui.gd, got %q", data)
+	}
+	if data, _ := os.ReadFile(shippedPath); string(data) != "uid://shipped\n" {
+		t.Fatalf("expected --repair to restore shipped.gd.uid, got %q", data)
+	}
+	if data, _ := os.ReadFile(uidPath); string(data) != "uid://abc\n" {
+		t.Fatalf("expected --repair to keep ui.gd.uid, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(addonDir, "icon.svg.import")); !os.IsNotExist(err) {
+		t.Fatalf("expected --repair to drop the import file of a missing asset, got err=%v", err)
+	}
+
+	// A lock without a tree hash takes its baseline from the pinned package,
+	// so edits made before the hash was recorded still show as drift.
+	lockPath := filepath.Join(projectDir, manifest.LockFilename)
+	lock, err := manifest.LoadLock(lockPath)
+	if err != nil {
+		t.Fatalf("load lock: %v", err)
+	}
+	pinnedTree := lock.Plugins["@user/ui"].Tree
+	locked := lock.Plugins["@user/ui"]
+	locked.Tree = ""
+	lock.Plugins["@user/ui"] = locked
+	if err := manifest.SaveLock(lockPath, lock); err != nil {
+		t.Fatalf("save lock: %v", err)
+	}
+	if err := os.WriteFile(scriptPath, []byte("extends Node2D\n"), 0o644); err != nil {
+		t.Fatalf("modify addon: %v", err)
+	}
+
+	if err := Install(context.Background(), InstallOptions{Offline: true}); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	lock, err = manifest.LoadLock(lockPath)
+	if err != nil {
+		t.Fatalf("load lock: %v", err)
+	}
+	if got := lock.Plugins["@user/ui"].Tree; got != pinnedTree {
+		t.Fatalf("expected tree hash of the pinned package %s, got %s", pinnedTree, got)
+	}
+	if data, _ := os.ReadFile(scriptPath); string(data) != "extends Node2D\n" {
+		t.Fatalf("expected install without --repair to leave local changes, got %q", data)
+	}
+	if err := Install(context.Background(), InstallOptions{Offline: true, Frozen: true}); err == nil {
+		t.Fatalf("expected frozen install to reject the drifted addon")
+	}
+}
@@ -20,8 +20,6 @@ type LinkOptions struct {
 }
 
 func Link(ctx context.Context, opts LinkOptions) error {
-	_ = ctx
-
 	specInput := strings.TrimSpace(opts.Spec)
 	if specInput == "" {
 		return fmt.Errorf("%w: missing plugin spec", ErrUserInput)
@@ -48,6 +46,12 @@ func Link(ctx context.Context, opts LinkOptions) error {
 		return fmt.Errorf("%w: no gdpm.json found (run `gdpm init`)", ErrUserInput)
 	}
 
+	unlock, err := lockProject(ctx, projectDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	manifestPath := filepath.Join(projectDir, "gdpm.json")
 	m, err := manifest.Load(manifestPath)
 	if err != nil {
@@ -105,25 +109,20 @@ func Link(ctx context.Context, opts LinkOptions) error {
 		return err
 	}
 
-	localAddonsDir := filepath.Join(projectDir, "addons")
-	if err := os.MkdirAll(localAddonsDir, 0o755); err != nil {
-		return err
-	}
-
-	dst := filepath.Join(localAddonsDir, addonDirName)
-	if err := fsutil.RemoveAll(dst); err != nil {
+	tx, err := beginTransaction(projectDir)
+	if err != nil {
 		return err
 	}
+	defer tx.rollback()
 
-	if err := fsutil.SymlinkDir(abs, dst); err != nil {
+	dst := filepath.Join(projectDir, "addons", addonDirName)
+	if err := tx.symlinkAddon(abs, dst); err != nil {
 		return err
 	}
 
 	if ok, err := pluginCfgExistsAtDirRoot(dst); err != nil {
-		_ = fsutil.RemoveAll(dst)
 		return fmt.Errorf("%w: %v", ErrUserInput, err)
 	} else if !ok {
-		_ = fsutil.RemoveAll(dst)
 		return fmt.Errorf("%w: linked addon is missing plugin.cfg at %s", ErrUserInput, filepath.Join(dst, "plugin.cfg"))
 	}
 
@@ -165,7 +164,7 @@ func Link(ctx context.Context, opts LinkOptions) error {
 	}
 
 	fmt.Printf("linked %s -> %s\n", pluginKey, storedPath)
-	return nil
+	return tx.commit()
 }
 
 func disableEditorPluginAliases(projectGodotPath, projectDir string, m manifest.Manifest, pluginKey, addonDirName, abs string) error {
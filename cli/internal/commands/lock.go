@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+	"github.com/aviorstudio/gdpm/cli/internal/gitcli"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+	"github.com/aviorstudio/gdpm/cli/internal/semver"
+)
+
+func loadProjectLock(projectDir string) (manifest.Lock, bool, error) {
+	lock, err := manifest.LoadLock(filepath.Join(projectDir, manifest.LockFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return manifest.NewLock(), false, nil
+		}
+		return manifest.Lock{}, false, fmt.Errorf("invalid %s: %w", manifest.LockFilename, err)
+	}
+	return lock, true, nil
+}
+
+func saveProjectLockIfChanged(projectDir string, lock, prev manifest.Lock, existed bool) error {
+	if existed && reflect.DeepEqual(lock.Plugins, prev.Plugins) {
+		return nil
+	}
+	if !existed && len(lock.Plugins) == 0 {
+		return nil
+	}
+	return manifest.SaveLock(filepath.Join(projectDir, manifest.LockFilename), lock)
+}
+
+func setProjectLockedPlugin(projectDir, pluginKey string, locked *manifest.LockedPlugin) error {
+	lock, existed, err := loadProjectLock(projectDir)
+	if err != nil {
+		return err
+	}
+
+	next := manifest.NewLock()
+	for key, entry := range lock.Plugins {
+		next.Plugins[key] = entry
+	}
+	if locked == nil {
+		delete(next.Plugins, pluginKey)
+	} else {
+		entry := *locked
+		if prev, ok := lock.Plugins[pluginKey]; ok && prev.SameCommit(entry) && entry.Zipball == "" && entry.Tree == "" {
+			entry.Zipball = prev.Zipball
+			entry.Tree = prev.Tree
+		}
+		if prev, ok := lock.Plugins[pluginKey]; ok && prev.SameCommit(entry) && entry.Dependencies == nil {
+			entry.Dependencies = prev.Dependencies
+		}
+		next.Plugins[pluginKey] = entry
+	}
+	return saveProjectLockIfChanged(projectDir, next, lock, existed)
+}
+
+func lockedSourceForPlugin(plugin manifest.Plugin) (manifest.LockedPlugin, bool, error) {
+	repoURL := strings.TrimSpace(plugin.Repo)
+	if repoURL == "" {
+		return manifest.LockedPlugin{}, false, nil
+	}
+	var locked manifest.LockedPlugin
+	if gdpmdb.IsGitSourceURL(repoURL) {
+		remote, ref, repoSubdir, err := gdpmdb.ParseGitSourceURL(repoURL)
+		if err != nil {
+			return manifest.LockedPlugin{}, false, err
+		}
+		if !gitcli.IsCommitSHA(ref) {
+			return manifest.LockedPlugin{}, false, fmt.Errorf("git repo must be pinned to a commit SHA (%s#<sha>)", "git+"+remote)
+		}
+		owner, repo := gdpmdb.GitRemoteOwnerRepo(remote)
+		locked = manifest.LockedPlugin{
+			Owner:  owner,
+			Repo:   repo,
+			Git:    remote,
+			Subdir: repoSubdir,
+			SHA:    strings.ToLower(ref),
+		}
+	} else {
+		host, owner, repo, ref, repoSubdir, err := gdpmdb.ParseTreeURLWithPath(repoURL)
+		if err != nil {
+			return manifest.LockedPlugin{}, false, err
+		}
+		locked = manifest.LockedPlugin{
+			Owner:  owner,
+			Repo:   repo,
+			Host:   host,
+			Subdir: repoSubdir,
+			SHA:    ref,
+		}
+	}
+	if version := strings.TrimSpace(plugin.Version); semver.IsExact(version) {
+		locked.Version = version
+	}
+	return locked, true, nil
+}
+
+func lockedSourceForResolved(resolved gdpmdb.ResolvedPlugin) manifest.LockedPlugin {
+	return manifest.LockedPlugin{
+		Owner:   resolved.GitHubOwner,
+		Repo:    resolved.GitHubRepo,
+		Host:    resolved.Host,
+		Git:     resolved.GitRemote,
+		Subdir:  resolved.GitHubSubdir,
+		SHA:     resolved.SHA,
+		Version: resolved.Version,
+	}
+}
+
+func repoURLForResolved(resolved gdpmdb.ResolvedPlugin) string {
+	if resolved.GitRemote != "" {
+		return gdpmdb.GitSourceURL(resolved.GitRemote, resolved.SHA, resolved.GitHubSubdir)
+	}
+	return gdpmdb.TreeURLWithPath(resolved.Host, resolved.GitHubOwner, resolved.GitHubRepo, resolved.SHA, resolved.GitHubSubdir)
+}
+
+func lockedPluginMatches(locked manifest.LockedPlugin, plugin manifest.Plugin) (bool, error) {
+	source, ok, err := lockedSourceForPlugin(plugin)
+	if err != nil || !ok {
+		return false, err
+	}
+	if !locked.SameCommit(source) {
+		return false, nil
+	}
+	return versionSatisfies(plugin.Version, locked.Version), nil
+}
+
+func resolvedPluginVersion(lock manifest.Lock, pluginKey string, plugin manifest.Plugin) string {
+	if locked, ok := lock.Plugins[pluginKey]; ok && locked.Version != "" {
+		if matches, err := lockedPluginMatches(locked, plugin); err == nil && matches {
+			return locked.Version
+		}
+	}
+	if version := strings.TrimSpace(plugin.Version); semver.IsExact(version) {
+		return version
+	}
+	return ""
+}
+
+func versionSatisfies(constraint, version string) bool {
+	constraint = strings.TrimSpace(constraint)
+	version = strings.TrimSpace(version)
+	if constraint == "" {
+		return true
+	}
+	c, err := semver.ParseConstraint(constraint)
+	if err != nil {
+		return constraint == version
+	}
+	v, ok := semver.Parse(version)
+	return ok && c.Matches(v)
+}
+
+func lockMismatches(m manifest.Manifest, lock manifest.Lock) ([]string, error) {
+	var mismatched []string
+	for pluginKey, plugin := range m.Plugins {
+		_, ok, err := lockedSourceForPlugin(plugin)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid repo for %s: %v", ErrUserInput, pluginKey, err)
+		}
+		locked, inLock := lock.Plugins[pluginKey]
+		if ok != inLock {
+			mismatched = append(mismatched, pluginKey)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if matches, err := lockedPluginMatches(locked, plugin); err != nil {
+			return nil, err
+		} else if !matches {
+			mismatched = append(mismatched, pluginKey)
+		}
+	}
+	sort.Strings(mismatched)
+	return mismatched, nil
+}
@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+	"github.com/aviorstudio/gdpm/cli/internal/project"
+	"github.com/aviorstudio/gdpm/cli/internal/semver"
+	"github.com/aviorstudio/gdpm/cli/internal/spec"
+)
+
+type OutdatedOptions struct{}
+
+type outdatedPlugin struct {
+	pluginKey string
+	current   string
+	wanted    string
+	latest    string
+}
+
+func Outdated(ctx context.Context, opts OutdatedOptions) error {
+	_ = opts
+
+	startDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	projectDir, ok := project.FindManifestDir(startDir)
+	if !ok {
+		return fmt.Errorf("%w: no gdpm.json found (run `gdpm init`)", ErrUserInput)
+	}
+
+	manifestPath := filepath.Join(projectDir, "gdpm.json")
+	m, err := manifest.Load(manifestPath)
+	if err != nil {
+		return err
+	}
+	lock, _, err := loadProjectLock(projectDir)
+	if err != nil {
+		return err
+	}
+
+	pluginKeys := make([]string, 0, len(m.Plugins))
+	for key, plugin := range m.Plugins {
+		if strings.TrimSpace(plugin.Repo) == "" || pluginFromRepo(plugin) {
+			continue
+		}
+		pluginKeys = append(pluginKeys, key)
+	}
+	sort.Strings(pluginKeys)
+
+	db, err := openRegistry()
+	if err != nil {
+		return err
+	}
+
+	var outdated []outdatedPlugin
+	for _, pluginKey := range pluginKeys {
+		pkg, err := spec.ParsePackageSpec(pluginKey)
+		if err != nil {
+			return fmt.Errorf("%w: invalid plugin key in gdpm.json: %s (%v)", ErrUserInput, pluginKey, err)
+		}
+		versions, err := db.ListPluginVersions(ctx, pkg.Owner, pkg.Repo)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pluginKey, err)
+		}
+
+		plugin := m.Plugins[pluginKey]
+		row := outdatedPluginFor(pluginKey, resolvedPluginVersion(lock, pluginKey, plugin), plugin.Version, versions)
+		if row.behind() {
+			outdated = append(outdated, row)
+		}
+	}
+
+	if len(outdated) == 0 {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Plugin\tCurrent\tWanted\tLatest")
+	for _, row := range outdated {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", row.pluginKey, displayVersion(row.current), displayVersion(row.wanted), displayVersion(row.latest))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return ErrOutdated
+}
+
+func outdatedPluginFor(pluginKey, current, constraint string, versions []gdpmdb.PluginVersion) outdatedPlugin {
+	row := outdatedPlugin{
+		pluginKey: pluginKey,
+		current:   strings.TrimSpace(current),
+	}
+	if latest, ok := gdpmdb.SelectPluginVersion(versions, "", false); ok {
+		row.latest = latest.String()
+	}
+	constraint = strings.TrimSpace(constraint)
+	if constraint == "" {
+		row.wanted = row.latest
+	} else if wanted, ok := gdpmdb.SelectPluginVersion(versions, constraint, false); ok {
+		row.wanted = wanted.String()
+	}
+	return row
+}
+
+func (p outdatedPlugin) behind() bool {
+	current, ok := semver.Parse(p.current)
+	if !ok {
+		return false
+	}
+	for _, candidate := range []string{p.wanted, p.latest} {
+		v, ok := semver.Parse(candidate)
+		if ok && semver.Compare(v, current) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func displayVersion(version string) string {
+	if strings.TrimSpace(version) == "" {
+		return "-"
+	}
+	return version
+}
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+)
+
+func TestOutdatedPluginFor(t *testing.T) {
+	versions := []gdpmdb.PluginVersion{
+		{Major: 1, Minor: 2, Patch: 0, SHA: "aaa"},
+		{Major: 1, Minor: 2, Patch: 3, SHA: "bbb"},
+		{Major: 1, Minor: 4, Patch: 0, SHA: "ccc"},
+		{Major: 2, Minor: 0, Patch: 0, SHA: "ddd"},
+	}
+
+	for _, tc := range []struct {
+		current    string
+		constraint string
+		wanted     string
+		latest     string
+		behind     bool
+	}{
+		{"1.2.0", "~1.2.0", "1.2.3", "2.0.0", true},
+		{"1.4.0", "^1.2", "1.4.0", "2.0.0", true},
+		{"1.2.0", "1.2.0", "1.2.0", "2.0.0", true},
+		{"2.0.0", "2.0.0", "2.0.0", "2.0.0", false},
+		{"", "^1.2", "1.4.0", "2.0.0", false},
+	} {
+		got := outdatedPluginFor("@user/plugin", tc.current, tc.constraint, versions)
+		if got.wanted != tc.wanted || got.latest != tc.latest {
+			t.Fatalf("%s (%s): unexpected wanted/latest: %#v", tc.current, tc.constraint, got)
+		}
+		if got.behind() != tc.behind {
+			t.Fatalf("%s (%s): expected behind=%v", tc.current, tc.constraint, tc.behind)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+	"github.com/aviorstudio/gdpm/cli/internal/project"
+)
+
+func isLocalPathSpec(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "." || s == ".." {
+		return true
+	}
+	for _, prefix := range []string{"./", "../", ".\\", "..\\"} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return filepath.IsAbs(s)
+}
+
+func pluginPathDir(projectDir, pluginPath string) (string, error) {
+	abs, err := pluginAbsPath(projectDir, filepath.FromSlash(pluginPath))
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%w: path does not exist: %s", ErrUserInput, abs)
+		}
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%w: path is not a directory: %s", ErrUserInput, abs)
+	}
+	if ok, err := pluginCfgExistsAtDirRoot(abs); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUserInput, err)
+	} else if !ok {
+		return "", fmt.Errorf("%w: plugin.cfg not found at %s", ErrUserInput, filepath.Join(abs, "plugin.cfg"))
+	}
+	return abs, nil
+}
+
+func installPathPlugin(tx *transaction, projectDir, addonDirName, pluginPath string) (bool, error) {
+	abs, err := pluginPathDir(projectDir, pluginPath)
+	if err != nil {
+		return false, err
+	}
+
+	dst := filepath.Join(projectDir, "addons", addonDirName)
+	if target, err := os.Readlink(dst); err == nil && filepath.Clean(target) == abs {
+		return false, nil
+	}
+	if err := tx.symlinkAddon(abs, dst); err != nil {
+		return false, err
+	}
+
+	projectGodotPath := filepath.Join(projectDir, "project.godot")
+	if _, err := os.Stat(projectGodotPath); err == nil {
+		pluginCfgResPath := "res://" + path.Join("addons", addonDirName, "plugin.cfg")
+		updated, err := project.SetEditorPluginEnabled(projectGodotPath, pluginCfgResPath, true)
+		if err != nil {
+			return false, err
+		}
+		if updated {
+			fmt.Printf("enabled %s\n", pluginCfgResPath)
+		}
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+func addPathPlugin(ctx context.Context, tx *transaction, projectDir string, m manifest.Manifest, input, alias string) error {
+	if strings.TrimSpace(alias) == "" {
+		return fmt.Errorf("%w: adding a local path needs a plugin name (use --as @owner/name)", ErrUserInput)
+	}
+	pluginKey, err := repoPluginKey("", "", "", alias)
+	if err != nil {
+		return err
+	}
+
+	expanded, err := fsutil.ExpandHome(strings.TrimSpace(input))
+	if err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(expanded)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(projectDir, abs)
+	if err != nil {
+		return fmt.Errorf("%w: %s cannot be expressed relative to %s", ErrUserInput, abs, projectDir)
+	}
+	pluginPath := filepath.ToSlash(rel)
+	if _, err := pluginPathDir(projectDir, pluginPath); err != nil {
+		return err
+	}
+
+	addonDirName, err := addonDirNameForPluginKey(pluginKey)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	if err := validateNoAddonDirCollision(m, pluginKey, addonDirName); err != nil {
+		return err
+	}
+
+	existing, hasExisting := m.Plugins[pluginKey]
+	dst := filepath.Join(projectDir, "addons", addonDirName)
+	if !hasExisting {
+		if _, err := os.Lstat(dst); err == nil {
+			return fmt.Errorf("%w: destination already exists: %s", ErrUserInput, dst)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	plugin := manifest.Plugin{Path: pluginPath}
+	if hasExisting {
+		plugin.Link = existing.Link
+	}
+	if !pluginLinkEnabled(plugin) {
+		if _, err := installPathPlugin(tx, projectDir, addonDirName, pluginPath); err != nil {
+			return err
+		}
+	}
+
+	m = manifest.UpsertPlugin(m, pluginKey, plugin)
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		return err
+	}
+	if err := setProjectLockedPlugin(projectDir, pluginKey, nil); err != nil {
+		return err
+	}
+
+	if pluginLinkEnabled(plugin) {
+		fmt.Printf("updated %s (linked)\n", pluginKey)
+	} else {
+		fmt.Printf("installed %s (%s)\n", pluginKey, pluginPath)
+	}
+	return syncDependencies(ctx, tx, projectDir)
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+)
+
+func TestAddAndInstall_FromLocalPath(t *testing.T) {
+	rootDir := t.TempDir()
+	libDir := filepath.Join(rootDir, "libs", "ui")
+	if err := os.MkdirAll(libDir, 0o755); err != nil {
+		t.Fatalf("mkdir lib: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(libDir, "plugin.cfg"), []byte("[plugin]\n"), 0o644); err != nil {
+		t.Fatalf("write plugin.cfg: %v", err)
+	}
+	projectDir := filepath.Join(rootDir, "game")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("mkdir project: %v", err)
+	}
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), manifest.New()); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Add(context.Background(), AddOptions{Spec: "../libs/ui"}); err == nil {
+		t.Fatalf("expected a local path without --as to be rejected")
+	}
+	if err := Add(context.Background(), AddOptions{Spec: "../libs/ui", As: "@studio/ui"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	m, err := manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		t.Fatalf("load gdpm.json: %v", err)
+	}
+	if plugin := m.Plugins["@studio/ui"]; plugin.Path != "../libs/ui" || plugin.Repo != "" {
+		t.Fatalf("unexpected manifest entry: %+v", plugin)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, manifest.LockFilename)); !os.IsNotExist(err) {
+		t.Fatalf("expected no lock entry for a path plugin (stat err=%v)", err)
+	}
+
+	dst := filepath.Join(projectDir, "addons", "@studio_ui")
+	assertLinkedTo := func(want string) {
+		t.Helper()
+		target, err := os.Readlink(dst)
+		if err != nil {
+			t.Fatalf("expected %s to be a symlink: %v", dst, err)
+		}
+		if target != want {
+			t.Fatalf("expected %s -> %s, got %s", dst, want, target)
+		}
+	}
+	assertLinkedTo(libDir)
+
+	if err := os.RemoveAll(filepath.Join(projectDir, "addons")); err != nil {
+		t.Fatalf("remove addons: %v", err)
+	}
+	if err := Install(context.Background(), InstallOptions{}); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	assertLinkedTo(libDir)
+
+	personalDir := filepath.Join(rootDir, "personal", "ui")
+	if err := os.MkdirAll(personalDir, 0o755); err != nil {
+		t.Fatalf("mkdir personal: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(personalDir, "plugin.cfg"), []byte("[plugin]\n"), 0o644); err != nil {
+		t.Fatalf("write plugin.cfg: %v", err)
+	}
+	if err := Link(context.Background(), LinkOptions{Spec: "@studio/ui", Path: personalDir}); err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	assertLinkedTo(personalDir)
+
+	if err := Unlink(context.Background(), UnlinkOptions{Spec: "@studio/ui"}); err != nil {
+		t.Fatalf("unlink: %v", err)
+	}
+	assertLinkedTo(libDir)
+
+	m, err = manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		t.Fatalf("load gdpm.json: %v", err)
+	}
+	if plugin := m.Plugins["@studio/ui"]; plugin.Path != "../libs/ui" || plugin.Link == nil || plugin.Link.Enabled {
+		t.Fatalf("unexpected manifest entry after unlink: %+v", plugin)
+	}
+}
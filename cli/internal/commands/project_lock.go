@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const projectLockFilename = ".gdpm-lock"
+
+var (
+	projectLockWait = 30 * time.Second
+	projectLockPoll = 200 * time.Millisecond
+
+	errProjectLocked = errors.New("project lock is held by another process")
+)
+
+type projectLockOwner struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Command string    `json:"command"`
+	Started time.Time `json:"started"`
+}
+
+func lockProject(ctx context.Context, projectDir string) (func(), error) {
+	lockPath := filepath.Join(projectDir, projectLockFilename)
+	deadline := time.Now().Add(projectLockWait)
+	waiting := false
+	for {
+		f, err := acquireProjectLock(lockPath)
+		if err == nil {
+			return func() { releaseProjectLock(f, lockPath) }, nil
+		}
+		if !errors.Is(err, errProjectLocked) {
+			return nil, err
+		}
+
+		holder := describeProjectLock(lockPath)
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("another gdpm process (%s) is working on %s", holder, projectDir)
+		}
+		if !waiting {
+			fmt.Fprintf(os.Stderr, "waiting for another gdpm process (%s) to finish...\n", holder)
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(projectLockPoll):
+		}
+	}
+}
+
+func acquireProjectLock(lockPath string) (*os.File, error) {
+	for {
+		f, err := openProjectLock(lockPath)
+		if err != nil {
+			return nil, err
+		}
+		// The previous holder deletes the file on release; a lock taken on a
+		// file that is no longer at lockPath does not exclude anyone.
+		held, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		current, err := os.Stat(lockPath)
+		if err != nil && !os.IsNotExist(err) {
+			_ = f.Close()
+			return nil, err
+		}
+		if err != nil || !os.SameFile(held, current) {
+			_ = f.Close()
+			continue
+		}
+
+		if err := writeProjectLockOwner(f); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return f, nil
+	}
+}
+
+func writeProjectLockOwner(f *os.File) error {
+	host, _ := os.Hostname()
+	data, err := json.Marshal(projectLockOwner{
+		PID:     os.Getpid(),
+		Host:    host,
+		Command: strings.Join(append([]string{"gdpm"}, os.Args[1:]...), " "),
+		Started: time.Now().UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err = f.WriteAt(append(data, '\n'), 0)
+	return err
+}
+
+func releaseProjectLock(f *os.File, lockPath string) {
+	// Only a lock file that still names this process is deleted.
+	if owner, ok := readProjectLock(lockPath); !ok || owner.PID != os.Getpid() {
+		_ = f.Close()
+		return
+	}
+	removeProjectLock(f, lockPath)
+}
+
+func projectLockHolder(lockPath string) (string, bool, error) {
+	f, err := openProjectLock(lockPath)
+	if errors.Is(err, errProjectLocked) {
+		if owner, ok := readProjectLock(lockPath); ok && owner.PID == os.Getpid() {
+			return "", false, nil
+		}
+		return describeProjectLock(lockPath), true, nil
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return "", false, f.Close()
+}
+
+func describeProjectLock(lockPath string) string {
+	if owner, ok := readProjectLock(lockPath); ok {
+		return owner.String()
+	}
+	return "pid unknown"
+}
+
+func readProjectLock(lockPath string) (projectLockOwner, bool) {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return projectLockOwner{}, false
+	}
+	var owner projectLockOwner
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return projectLockOwner{}, false
+	}
+	return owner, true
+}
+
+func (o projectLockOwner) String() string {
+	s := fmt.Sprintf("pid %d", o.PID)
+	if host, _ := os.Hostname(); o.Host != "" && o.Host != host {
+		s += " on " + o.Host
+	}
+	if o.Command != "" {
+		s += ", `" + o.Command + "`"
+	}
+	return s
+}
@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockProject_FailsWhileHeld(t *testing.T) {
+	projectDir := t.TempDir()
+	oldWait := projectLockWait
+	projectLockWait = 0
+	t.Cleanup(func() { projectLockWait = oldWait })
+
+	unlock, err := lockProject(context.Background(), projectDir)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+
+	_, err = lockProject(context.Background(), projectDir)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("pid %d", os.Getpid())) {
+		t.Fatalf("expected second lock to fail naming the owner, got: %v", err)
+	}
+
+	unlock()
+	if _, err := os.Stat(filepath.Join(projectDir, projectLockFilename)); !os.IsNotExist(err) {
+		t.Fatalf("expected lock file to be removed, got err=%v", err)
+	}
+	unlock, err = lockProject(context.Background(), projectDir)
+	if err != nil {
+		t.Fatalf("lock after release: %v", err)
+	}
+	unlock()
+}
+
+func TestLockProject_WaitsUntilCanceled(t *testing.T) {
+	projectDir := t.TempDir()
+	unlock, err := lockProject(context.Background(), projectDir)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := lockProject(ctx, projectDir); err != context.DeadlineExceeded {
+		t.Fatalf("expected waiting lock to stop with the context, got: %v", err)
+	}
+}
+
+func TestLockProject_ReplacesStaleLock(t *testing.T) {
+	projectDir := t.TempDir()
+	host, _ := os.Hostname()
+	data, err := json.Marshal(projectLockOwner{PID: 999999999, Host: host, Command: "gdpm install"})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	lockPath := filepath.Join(projectDir, projectLockFilename)
+	if err := os.WriteFile(lockPath, data, 0o644); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+
+	unlock, err := lockProject(context.Background(), projectDir)
+	if err != nil {
+		t.Fatalf("expected stale lock to be replaced, got: %v", err)
+	}
+	defer unlock()
+
+	owner, ok := readProjectLock(lockPath)
+	if !ok || owner.PID != os.Getpid() {
+		t.Fatalf("expected lock to be owned by this process, got %+v", owner)
+	}
+}
+
+func TestLockProject_ExcludesConcurrentHolders(t *testing.T) {
+	projectDir := t.TempDir()
+	oldPoll := projectLockPoll
+	projectLockPoll = time.Millisecond
+	t.Cleanup(func() { projectLockPoll = oldPoll })
+
+	var mu sync.Mutex
+	holders, maxHolders := 0, 0
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				unlock, err := lockProject(context.Background(), projectDir)
+				if err != nil {
+					errs <- err
+					return
+				}
+				mu.Lock()
+				holders++
+				if holders > maxHolders {
+					maxHolders = holders
+				}
+				mu.Unlock()
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				holders--
+				mu.Unlock()
+				unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("lock: %v", err)
+	}
+	if maxHolders != 1 {
+		t.Fatalf("expected one holder at a time, got %d", maxHolders)
+	}
+}
+
+func TestLockProject_ReleaseKeepsLockItDoesNotOwn(t *testing.T) {
+	projectDir := t.TempDir()
+	lockPath := filepath.Join(projectDir, projectLockFilename)
+
+	unlock, err := lockProject(context.Background(), projectDir)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	data, err := json.Marshal(projectLockOwner{PID: os.Getpid() + 1, Command: "gdpm install"})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if err := os.WriteFile(lockPath, data, 0o644); err != nil {
+		t.Fatalf("write lock: %v", err)
+	}
+
+	unlock()
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Fatalf("expected a lock file naming another process to be kept: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package commands
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func openProjectLock(lockPath string) (*os.File, error) {
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	// flock is released by the kernel when the process exits, so a crashed
+	// gdpm never leaves the project locked.
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errProjectLocked
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+func removeProjectLock(f *os.File, lockPath string) {
+	// Removing before unlocking makes waiters that opened the old file see it
+	// is gone and start over on a new one.
+	_ = os.Remove(lockPath)
+	_ = f.Close()
+}
@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"os"
+	"syscall"
+)
+
+const errorSharingViolation syscall.Errno = 32
+
+func openProjectLock(lockPath string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	// Without FILE_SHARE_WRITE no other process can open the file for writing
+	// until this handle is closed, which Windows also does when the process
+	// exits. Others can still read the owner record.
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, syscall.FILE_SHARE_READ, nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if err == errorSharingViolation || err == syscall.ERROR_ACCESS_DENIED {
+			return nil, errProjectLocked
+		}
+		return nil, &os.PathError{Op: "open", Path: lockPath, Err: err}
+	}
+	return os.NewFile(uintptr(h), lockPath), nil
+}
+
+func removeProjectLock(f *os.File, lockPath string) {
+	// An open handle keeps the file from being deleted on Windows. If another
+	// process opens it in between, the delete fails and its lock stays.
+	_ = f.Close()
+	_ = os.Remove(lockPath)
+}
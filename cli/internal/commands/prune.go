@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+	"github.com/aviorstudio/gdpm/cli/internal/project"
+)
+
+type PruneOptions struct {
+	DryRun bool
+	Yes    bool
+}
+
+var (
+	stdinIsTerminal = func() bool {
+		info, err := os.Stdin.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+	confirmInput io.Reader = os.Stdin
+)
+
+func Prune(ctx context.Context, opts PruneOptions) error {
+	startDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	projectDir, ok := project.FindManifestDir(startDir)
+	if !ok {
+		return fmt.Errorf("%w: no gdpm.json found (run `gdpm init`)", ErrUserInput)
+	}
+
+	unlock, err := lockProject(ctx, projectDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	m, err := manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		return err
+	}
+	lock, _, err := loadProjectLock(projectDir)
+	if err != nil {
+		return err
+	}
+
+	orphans, err := orphanedAddonDirs(projectDir, m, lock)
+	if err != nil {
+		return err
+	}
+	if len(orphans) == 0 {
+		fmt.Println("nothing to prune")
+		return nil
+	}
+	if opts.DryRun {
+		for _, name := range orphans {
+			fmt.Printf("would remove addons/%s\n", name)
+		}
+		return nil
+	}
+	confirmed, err := confirmPrune(orphans, opts.Yes)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return fmt.Errorf("%w: prune cancelled; nothing was removed", ErrUserInput)
+	}
+
+	tx, err := beginTransaction(projectDir)
+	if err != nil {
+		return err
+	}
+	defer tx.rollback()
+
+	if err := pruneAddonDirs(tx, projectDir, orphans); err != nil {
+		return err
+	}
+	return tx.commit()
+}
+
+// confirmPrune lists the addon directories about to be removed and asks
+// before removing them. Without a terminal to ask on, only --yes allows it.
+func confirmPrune(names []string, yes bool) (bool, error) {
+	for _, name := range names {
+		fmt.Printf("will remove addons/%s\n", name)
+	}
+	if yes {
+		return true, nil
+	}
+	if !stdinIsTerminal() {
+		return false, fmt.Errorf("%w: not removing addons without confirmation; stdin is not a terminal (pass --yes)", ErrUserInput)
+	}
+	return confirm("remove these addons?")
+}
+
+func confirm(question string) (bool, error) {
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := bufio.NewReader(confirmInput).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	if err == io.EOF {
+		fmt.Println()
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// lockedDependencyKeys returns the gdpm.lock entries that a plugin in
+// gdpm.json still needs, directly or through other dependencies. Lock
+// entries left behind by a plugin removed from gdpm.json by hand are not
+// included.
+func lockedDependencyKeys(m manifest.Manifest, lock manifest.Lock) []string {
+	seen := map[string]bool{}
+	queue := sortedKeys(m.Plugins)
+	for len(queue) > 0 {
+		pluginKey := queue[0]
+		queue = queue[1:]
+		locked, ok := lock.Plugins[pluginKey]
+		if !ok {
+			continue
+		}
+		for _, dep := range sortedKeys(locked.Dependencies) {
+			if seen[dep] {
+				continue
+			}
+			seen[dep] = true
+			queue = append(queue, dep)
+		}
+	}
+
+	var keys []string
+	for _, pluginKey := range sortedKeys(lock.Plugins) {
+		if _, ok := m.Plugins[pluginKey]; ok {
+			continue
+		}
+		if seen[pluginKey] {
+			keys = append(keys, pluginKey)
+		}
+	}
+	return keys
+}
+
+func orphanedAddonDirs(projectDir string, m manifest.Manifest, lock manifest.Lock) ([]string, error) {
+	owned := map[string]bool{}
+	for _, keys := range [][]string{sortedKeys(m.Plugins), lockedDependencyKeys(m, lock)} {
+		for _, pluginKey := range keys {
+			if addonDirName, err := addonDirNameForPluginKey(pluginKey); err == nil {
+				owned[addonDirName] = true
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(projectDir, "addons"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var orphans []string
+	for _, entry := range entries {
+		name := entry.Name()
+		// Only directories named like gdpm installs are candidates; addons
+		// managed by hand (addons/gut, ...) are never touched.
+		if owned[name] || !addonDirNameRe.MatchString(name) {
+			continue
+		}
+		if !entry.IsDir() && entry.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+		orphans = append(orphans, name)
+	}
+	return orphans, nil
+}
+
+func pruneAddonDirs(tx *transaction, projectDir string, names []string) error {
+	projectGodotPath := filepath.Join(projectDir, "project.godot")
+	hasProjectGodot := false
+	if _, err := os.Stat(projectGodotPath); err == nil {
+		hasProjectGodot = true
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, name := range names {
+		if hasProjectGodot {
+			pluginCfgResPath := "res://" + path.Join("addons", name, "plugin.cfg")
+			updated, err := project.SetEditorPluginEnabled(projectGodotPath, pluginCfgResPath, false)
+			if err != nil {
+				return err
+			}
+			if updated {
+				fmt.Printf("disabled %s\n", pluginCfgResPath)
+			}
+		}
+		if err := tx.removeAddon(filepath.Join(projectDir, "addons", name)); err != nil {
+			return err
+		}
+		fmt.Printf("removed addons/%s\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aviorstudio/gdpm/cli/internal/cache"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+)
+
+func TestPrune_RemovesUnownedAddonDirs(t *testing.T) {
+	projectDir := t.TempDir()
+
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@user/ui", manifest.Plugin{})
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), m); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+	// @user/old was removed from gdpm.json by hand and is still in the lock,
+	// along with its dependency.
+	lock := manifest.NewLock()
+	lock.Plugins["@user/ui"] = manifest.LockedPlugin{Owner: "user", Repo: "ui", SHA: "abc", Dependencies: map[string]string{"@dep/tween": "^1.0.0"}}
+	lock.Plugins["@dep/tween"] = manifest.LockedPlugin{Owner: "dep", Repo: "tween", SHA: "abc", Version: "1.0.0"}
+	lock.Plugins["@user/old"] = manifest.LockedPlugin{Owner: "user", Repo: "old", SHA: "abc", Dependencies: map[string]string{"@dep/legacy": "^1.0.0"}}
+	lock.Plugins["@dep/legacy"] = manifest.LockedPlugin{Owner: "dep", Repo: "legacy", SHA: "abc", Version: "1.0.0"}
+	if err := manifest.SaveLock(filepath.Join(projectDir, manifest.LockFilename), lock); err != nil {
+		t.Fatalf("write gdpm.lock: %v", err)
+	}
+
+	addonsDir := filepath.Join(projectDir, "addons")
+	for _, dir := range []string{"@user_ui", "@dep_tween", "@user_old", "@dep_legacy", "gut"} {
+		if err := os.MkdirAll(filepath.Join(addonsDir, dir), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(addonsDir, dir, "plugin.cfg"), []byte("[plugin]\n"), 0o644); err != nil {
+			t.Fatalf("write plugin.cfg: %v", err)
+		}
+	}
+
+	projectGodotPath := filepath.Join(projectDir, "project.godot")
+	projectGodot := "config_version=5\n\n[editor_plugins]\nenabled=PackedStringArray(\"res://addons/@user_ui/plugin.cfg\", \"res://addons/@user_old/plugin.cfg\", \"res://addons/gut/plugin.cfg\")\n"
+	if err := os.WriteFile(projectGodotPath, []byte(projectGodot), 0o644); err != nil {
+		t.Fatalf("write project.godot: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	stubTerminal(t, false, "")
+
+	if err := Prune(context.Background(), PruneOptions{DryRun: true}); err != nil {
+		t.Fatalf("prune --dry-run: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(addonsDir, "@user_old")); err != nil {
+		t.Fatalf("expected --dry-run to keep addons/@user_old: %v", err)
+	}
+
+	if err := Prune(context.Background(), PruneOptions{}); !errors.Is(err, ErrUserInput) {
+		t.Fatalf("expected prune without a terminal to require --yes, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(addonsDir, "@user_old")); err != nil {
+		t.Fatalf("expected prune without --yes to keep addons/@user_old: %v", err)
+	}
+
+	if err := Prune(context.Background(), PruneOptions{Yes: true}); err != nil {
+		t.Fatalf("prune --yes: %v", err)
+	}
+	for _, dir := range []string{"@user_old", "@dep_legacy"} {
+		if _, err := os.Stat(filepath.Join(addonsDir, dir)); !os.IsNotExist(err) {
+			t.Fatalf("expected addons/%s to be removed, got err=%v", dir, err)
+		}
+	}
+	for _, dir := range []string{"@user_ui", "@dep_tween", "gut"} {
+		if _, err := os.Stat(filepath.Join(addonsDir, dir)); err != nil {
+			t.Fatalf("expected addons/%s to be kept: %v", dir, err)
+		}
+	}
+
+	data, err := os.ReadFile(projectGodotPath)
+	if err != nil {
+		t.Fatalf("read project.godot: %v", err)
+	}
+	got := string(data)
+	if strings.Contains(got, "@user_old") {
+		t.Fatalf("expected pruned plugin to be disabled, got:\n%s", got)
+	}
+	if !strings.Contains(got, "res://addons/@user_ui/plugin.cfg") || !strings.Contains(got, "res://addons/gut/plugin.cfg") {
+		t.Fatalf("expected other plugins to stay enabled, got:\n%s", got)
+	}
+	if dirs := stagingDirs(t, projectDir); len(dirs) != 0 {
+		t.Fatalf("expected no staging directories to be left, got %v", dirs)
+	}
+}
+
+func TestPrune_AsksBeforeRemovingFromTerminal(t *testing.T) {
+	projectDir := t.TempDir()
+
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), manifest.New()); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+	oldDir := filepath.Join(projectDir, "addons", "@user_old")
+	if err := os.MkdirAll(oldDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	stubTerminal(t, true, "n\n")
+	if err := Prune(context.Background(), PruneOptions{}); err == nil {
+		t.Fatalf("expected declined prune to fail")
+	}
+	if _, err := os.Stat(oldDir); err != nil {
+		t.Fatalf("expected declined prune to keep addons/@user_old: %v", err)
+	}
+
+	stubTerminal(t, true, "")
+	if err := Prune(context.Background(), PruneOptions{Yes: true}); err != nil {
+		t.Fatalf("prune --yes: %v", err)
+	}
+	if _, err := os.Stat(oldDir); !os.IsNotExist(err) {
+		t.Fatalf("expected --yes to remove addons/@user_old, got err=%v", err)
+	}
+}
+
+func TestInstall_PruneConfirmsBeforeRemoving(t *testing.T) {
+	projectDir := t.TempDir()
+	t.Setenv(cache.DirEnv, t.TempDir())
+
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), manifest.New()); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+	oldDir := filepath.Join(projectDir, "addons", "@user_old")
+	if err := os.MkdirAll(oldDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	stubTerminal(t, false, "")
+	if err := Install(context.Background(), InstallOptions{Prune: true}); !errors.Is(err, ErrUserInput) {
+		t.Fatalf("expected install --prune without a terminal to require --yes, got %v", err)
+	}
+
+	stubTerminal(t, true, "n\n")
+	if err := Install(context.Background(), InstallOptions{Prune: true}); err != nil {
+		t.Fatalf("install --prune: %v", err)
+	}
+	if _, err := os.Stat(oldDir); err != nil {
+		t.Fatalf("expected a declined prune to keep addons/@user_old: %v", err)
+	}
+
+	stubTerminal(t, false, "")
+	if err := Install(context.Background(), InstallOptions{Prune: true, Yes: true}); err != nil {
+		t.Fatalf("install --prune --yes: %v", err)
+	}
+	if _, err := os.Stat(oldDir); !os.IsNotExist(err) {
+		t.Fatalf("expected --yes to remove addons/@user_old, got err=%v", err)
+	}
+}
+
+func stubTerminal(t *testing.T, terminal bool, input string) {
+	t.Helper()
+
+	prevTerminal, prevInput := stdinIsTerminal, confirmInput
+	stdinIsTerminal = func() bool { return terminal }
+	confirmInput = strings.NewReader(input)
+	t.Cleanup(func() {
+		stdinIsTerminal, confirmInput = prevTerminal, prevInput
+	})
+}
@@ -8,7 +8,6 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
 	"github.com/aviorstudio/gdpm/cli/internal/manifest"
 	"github.com/aviorstudio/gdpm/cli/internal/project"
 	"github.com/aviorstudio/gdpm/cli/internal/spec"
@@ -19,8 +18,6 @@ type RemoveOptions struct {
 }
 
 func Remove(ctx context.Context, opts RemoveOptions) error {
-	_ = ctx
-
 	specInput := strings.TrimSpace(opts.Spec)
 	if specInput == "" {
 		return fmt.Errorf("%w: missing plugin spec", ErrUserInput)
@@ -39,6 +36,12 @@ func Remove(ctx context.Context, opts RemoveOptions) error {
 		return fmt.Errorf("%w: no gdpm.json found (run `gdpm init`)", ErrUserInput)
 	}
 
+	unlock, err := lockProject(ctx, projectDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	manifestPath := filepath.Join(projectDir, "gdpm.json")
 	m, err := manifest.Load(manifestPath)
 	if err != nil {
@@ -64,6 +67,12 @@ func Remove(ctx context.Context, opts RemoveOptions) error {
 
 	dst := filepath.Join(projectDir, "addons", addonDirName)
 
+	tx, err := beginTransaction(projectDir)
+	if err != nil {
+		return err
+	}
+	defer tx.rollback()
+
 	projectGodotPath := filepath.Join(projectDir, "project.godot")
 	if _, err := os.Stat(projectGodotPath); err == nil {
 		pluginCfgResPath := "res://" + path.Join("addons", addonDirName, "plugin.cfg")
@@ -78,7 +87,7 @@ func Remove(ctx context.Context, opts RemoveOptions) error {
 		return err
 	}
 
-	if err := fsutil.RemoveAll(dst); err != nil {
+	if err := tx.removeAddon(dst); err != nil {
 		return err
 	}
 
@@ -86,7 +95,13 @@ func Remove(ctx context.Context, opts RemoveOptions) error {
 	if err := manifest.Save(manifestPath, m); err != nil {
 		return err
 	}
+	if err := setProjectLockedPlugin(projectDir, pkg.Name(), nil); err != nil {
+		return err
+	}
+	if err := syncDependencies(ctx, tx, projectDir); err != nil {
+		return err
+	}
 
 	fmt.Printf("removed %s\n", pkg.Name())
-	return nil
+	return tx.commit()
 }
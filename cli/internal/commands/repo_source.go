@@ -0,0 +1,206 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+	"github.com/aviorstudio/gdpm/cli/internal/gitcli"
+	"github.com/aviorstudio/gdpm/cli/internal/giteaapi"
+	"github.com/aviorstudio/gdpm/cli/internal/githubapi"
+	"github.com/aviorstudio/gdpm/cli/internal/gitlabapi"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+	"github.com/aviorstudio/gdpm/cli/internal/semver"
+	"github.com/aviorstudio/gdpm/cli/internal/spec"
+)
+
+func isRepoURLSpec(s string) bool {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "@") {
+		return false
+	}
+	if strings.Contains(s, "://") || strings.HasPrefix(s, "git@") {
+		return true
+	}
+	host, _, ok := strings.Cut(s, "/")
+	return ok && strings.Contains(host, ".")
+}
+
+func splitRepoURLVersion(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	at := strings.LastIndex(s, "@")
+	if at < 0 || at < strings.LastIndex(s, "/") || at < strings.LastIndex(s, ":") {
+		return s, ""
+	}
+	return s[:at], strings.TrimSpace(s[at+1:])
+}
+
+func resolveRepoURLSpec(ctx context.Context, input, alias string) (string, gdpmdb.ResolvedPlugin, error) {
+	if gdpmdb.IsGitSourceURL(input) {
+		remote, ref, subdir, err := gdpmdb.ParseGitSourceURL(input)
+		if err != nil {
+			return "", gdpmdb.ResolvedPlugin{}, fmt.Errorf("%w: %v", ErrUserInput, err)
+		}
+		owner, repo := gdpmdb.GitRemoteOwnerRepo(remote)
+		pluginKey, err := repoPluginKey(owner, repo, subdir, alias)
+		if err != nil {
+			return "", gdpmdb.ResolvedPlugin{}, err
+		}
+		resolved, err := resolveGitSource(ctx, remote, ref, subdir)
+		if err != nil {
+			return "", gdpmdb.ResolvedPlugin{}, err
+		}
+		resolved.Name = pluginKey
+		return pluginKey, resolved, nil
+	}
+
+	repoURL, version := splitRepoURLVersion(input)
+	host, owner, repo, ref, subdir, err := gdpmdb.ParseSourceURL(repoURL)
+	if err != nil {
+		return "", gdpmdb.ResolvedPlugin{}, fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	if ref != "" && version != "" {
+		return "", gdpmdb.ResolvedPlugin{}, fmt.Errorf("%w: %s already names a ref (%s); drop @%s", ErrUserInput, repoURL, ref, version)
+	}
+	if version != "" {
+		ref = version
+	}
+
+	pluginKey, err := repoPluginKey(owner, repo, subdir, alias)
+	if err != nil {
+		return "", gdpmdb.ResolvedPlugin{}, err
+	}
+
+	resolved, err := resolveArchiveSource(ctx, host, owner, repo, ref, subdir)
+	if err != nil {
+		return "", gdpmdb.ResolvedPlugin{}, err
+	}
+	resolved.Name = pluginKey
+	return pluginKey, resolved, nil
+}
+
+func repoPluginKey(owner, repo, subdir, alias string) (string, error) {
+	if alias = strings.TrimSpace(alias); alias != "" {
+		if !strings.HasPrefix(alias, "@") {
+			alias = "@" + alias
+		}
+		pkg, err := spec.ParsePackageSpec(alias)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid --as: %v", ErrUserInput, err)
+		}
+		if pkg.Version != "" {
+			return "", fmt.Errorf("%w: --as does not take a version: %s", ErrUserInput, alias)
+		}
+		return pkg.Name(), nil
+	}
+
+	name := repo
+	if subdir != "" {
+		name = path.Base(subdir)
+	}
+	// GitLab namespaces can be nested groups; the innermost one names the plugin.
+	pkg, err := spec.ParsePackageSpec("@" + path.Base(owner) + "/" + name)
+	if err != nil {
+		return "", fmt.Errorf("%w: cannot derive a plugin name from %s/%s (use --as @owner/name): %v", ErrUserInput, owner, repo, err)
+	}
+	return pkg.Name(), nil
+}
+
+func pluginFromRepo(plugin manifest.Plugin) bool {
+	return plugin.Source == manifest.SourceRepo || gdpmdb.IsGitSourceURL(plugin.Repo)
+}
+
+func resolveRepoSource(ctx context.Context, source manifest.LockedPlugin, ref string) (gdpmdb.ResolvedPlugin, error) {
+	if source.Git != "" {
+		return resolveGitSource(ctx, source.Git, ref, source.Subdir)
+	}
+	return resolveArchiveSource(ctx, source.Host, source.Owner, source.Repo, ref, source.Subdir)
+}
+
+func resolveGitSource(ctx context.Context, remote, ref, subdir string) (gdpmdb.ResolvedPlugin, error) {
+	ref = strings.TrimSpace(ref)
+	if err := rejectVersionRange(ref, remote); err != nil {
+		return gdpmdb.ResolvedPlugin{}, err
+	}
+
+	resolvedRef, sha, err := gitcli.ResolveRefAndSHA(ctx, remote, ref)
+	if err != nil {
+		return gdpmdb.ResolvedPlugin{}, fmt.Errorf("%w: resolve %s: %v", ErrUserInput, remote, err)
+	}
+
+	owner, repo := gdpmdb.GitRemoteOwnerRepo(remote)
+	resolved := gdpmdb.ResolvedPlugin{
+		Repo:         remote,
+		GitHubOwner:  owner,
+		GitHubRepo:   repo,
+		GitHubSubdir: subdir,
+		GitRemote:    remote,
+		SHA:          strings.ToLower(sha),
+	}
+	if v, ok := semver.Parse(resolvedRef); ok {
+		resolved.Version = v.String()
+	}
+	return resolved, nil
+}
+
+func rejectVersionRange(ref, source string) error {
+	if c, err := semver.ParseConstraint(ref); err == nil && ref != "" {
+		if _, exact := c.Exact(); !exact {
+			return fmt.Errorf("%w: version ranges need the registry; pin a tag, branch or commit for %s", ErrUserInput, source)
+		}
+	}
+	return nil
+}
+
+func resolveArchiveSource(ctx context.Context, host, owner, repo, ref, subdir string) (gdpmdb.ResolvedPlugin, error) {
+	ref = strings.TrimSpace(ref)
+	if err := rejectVersionRange(ref, owner+"/"+repo); err != nil {
+		return gdpmdb.ResolvedPlugin{}, err
+	}
+
+	resolvedRef, sha := ref, ref
+	if !gitcli.IsCommitSHA(ref) {
+		var err error
+		switch gdpmdb.ForgeForHost(host) {
+		case gdpmdb.ForgeGitLab:
+			var gl *gitlabapi.Client
+			if gl, err = newGitLabClient(host); err != nil {
+				return gdpmdb.ResolvedPlugin{}, err
+			}
+			resolvedRef, sha, err = gl.ResolveRefAndSHA(ctx, owner+"/"+repo, ref)
+		case gdpmdb.ForgeGitea:
+			var gt *giteaapi.Client
+			if gt, err = newGiteaClient(host); err != nil {
+				return gdpmdb.ResolvedPlugin{}, err
+			}
+			resolvedRef, sha, err = gt.ResolveRefAndSHA(ctx, owner, repo, ref)
+		default:
+			var gh *githubapi.Client
+			if gh, err = newGitHubClient(host); err != nil {
+				return gdpmdb.ResolvedPlugin{}, err
+			}
+			resolvedRef, sha, err = gh.ResolveRefAndSHA(ctx, owner, repo, ref)
+		}
+		if err != nil {
+			return gdpmdb.ResolvedPlugin{}, fmt.Errorf("%w: resolve %s/%s: %v", ErrUserInput, owner, repo, err)
+		}
+	}
+
+	if gdpmdb.IsDefaultGitHubHost(host) {
+		host = "github.com"
+	}
+	resolved := gdpmdb.ResolvedPlugin{
+		Repo:         "https://" + host + "/" + owner + "/" + repo,
+		GitHubOwner:  owner,
+		GitHubRepo:   repo,
+		GitHubSubdir: subdir,
+		Host:         host,
+		SHA:          strings.ToLower(sha),
+	}
+	if v, ok := semver.Parse(resolvedRef); ok {
+		resolved.Version = v.String()
+	}
+	return resolved, nil
+}
@@ -0,0 +1,368 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aviorstudio/gdpm/cli/internal/cache"
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+)
+
+func TestRepoURLSpec(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		isURL   bool
+		url     string
+		version string
+	}{
+		{"@user/plugin", false, "", ""},
+		{"user/plugin", false, "", ""},
+		{"github.com/owner/repo", true, "github.com/owner/repo", ""},
+		{"github.com/owner/repo@v1.2.0", true, "github.com/owner/repo", "v1.2.0"},
+		{"https://github.com/owner/repo/tree/main/addons/ui", true, "https://github.com/owner/repo/tree/main/addons/ui", ""},
+		{"git@github.com:owner/repo.git", true, "git@github.com:owner/repo.git", ""},
+		{"git@github.com:owner/repo@main", true, "git@github.com:owner/repo", "main"},
+	} {
+		if got := isRepoURLSpec(tc.in); got != tc.isURL {
+			t.Fatalf("isRepoURLSpec(%q) = %v, want %v", tc.in, got, tc.isURL)
+		}
+		if !tc.isURL {
+			continue
+		}
+		url, version := splitRepoURLVersion(tc.in)
+		if url != tc.url || version != tc.version {
+			t.Fatalf("splitRepoURLVersion(%q) = %q, %q; want %q, %q", tc.in, url, version, tc.url, tc.version)
+		}
+	}
+}
+
+func TestRepoPluginKey(t *testing.T) {
+	for _, tc := range []struct {
+		owner, repo, subdir, alias string
+		want                       string
+	}{
+		{"Owner", "repo", "", "", "@owner/repo"},
+		{"owner", "monorepo", "addons/ui_kit", "", "@owner/ui_kit"},
+		{"owner", "repo", "", "@studio/ui", "@studio/ui"},
+		{"owner", "repo", "", "studio/ui", "@studio/ui"},
+	} {
+		got, err := repoPluginKey(tc.owner, tc.repo, tc.subdir, tc.alias)
+		if err != nil {
+			t.Fatalf("repoPluginKey(%+v): %v", tc, err)
+		}
+		if got != tc.want {
+			t.Fatalf("repoPluginKey(%+v) = %q, want %q", tc, got, tc.want)
+		}
+	}
+	if _, err := repoPluginKey("owner", "repo", "", "@studio/ui@1.0.0"); err == nil {
+		t.Fatalf("expected --as with a version to fail")
+	}
+}
+
+func TestAdd_FromGitHubURLPinnedToCommit(t *testing.T) {
+	projectDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv(cache.DirEnv, cacheDir)
+
+	sha := strings.Repeat("a", 40)
+	zipPath := filepath.Join(t.TempDir(), "repo.zip")
+	writeTestZipball(t, zipPath, "owner-monorepo-aaaaaaa", map[string]string{
+		"addons/ui_kit/plugin.cfg": "[plugin]\n",
+		"README.md":                "monorepo\n",
+	})
+	if err := cache.New(cacheDir, 0).StoreZipball("owner", "monorepo", sha, zipPath); err != nil {
+		t.Fatalf("store zipball: %v", err)
+	}
+
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), manifest.New()); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Add(context.Background(), AddOptions{Spec: "@studio/ui", As: "@studio/other"}); err == nil {
+		t.Fatalf("expected --as to be rejected for registry specs")
+	}
+
+	spec := "https://github.com/owner/monorepo/tree/" + sha + "/addons/ui_kit"
+	if err := Add(context.Background(), AddOptions{Spec: spec, As: "@studio/ui"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	m, err := manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		t.Fatalf("load gdpm.json: %v", err)
+	}
+	plugin, ok := m.Plugins["@studio/ui"]
+	if !ok {
+		t.Fatalf("expected @studio/ui in gdpm.json, got %+v", m.Plugins)
+	}
+	if plugin.Repo != spec || plugin.Source != manifest.SourceRepo || plugin.Version != "" {
+		t.Fatalf("unexpected manifest entry: %+v", plugin)
+	}
+
+	lock, err := manifest.LoadLock(filepath.Join(projectDir, manifest.LockFilename))
+	if err != nil {
+		t.Fatalf("load lock: %v", err)
+	}
+	if locked := lock.Plugins["@studio/ui"]; locked.Owner != "owner" || locked.Subdir != "addons/ui_kit" || locked.SHA != sha || locked.Tree == "" {
+		t.Fatalf("unexpected lock entry: %+v", locked)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "addons", "@studio_ui", "plugin.cfg")); err != nil {
+		t.Fatalf("expected addon to be installed: %v", err)
+	}
+}
+
+func newTestGitRemote(t *testing.T, files map[string]string) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	run := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=gdpm", "GIT_AUTHOR_EMAIL=gdpm@example.com",
+			"GIT_COMMITTER_NAME=gdpm", "GIT_COMMITTER_EMAIL=gdpm@example.com",
+		)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	work := t.TempDir()
+	run(work, "init", "-q", "-b", "main")
+	for name, content := range files {
+		p := filepath.Join(work, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	run(work, "add", ".")
+	run(work, "commit", "-q", "-m", "initial")
+	run(work, "tag", "v0.2.0")
+	sha := run(work, "rev-parse", "HEAD")
+
+	bare := filepath.Join(t.TempDir(), "studio", "addons.git")
+	run(work, "clone", "-q", "--bare", work, bare)
+	return "file://" + bare, sha
+}
+
+func TestAddAndInstall_FromGitRemote(t *testing.T) {
+	remote, sha := newTestGitRemote(t, map[string]string{
+		"addons/ui/plugin.cfg": "[plugin]\n",
+		"README.md":            "addons\n",
+	})
+	projectDir := t.TempDir()
+	t.Setenv(cache.DirEnv, t.TempDir())
+
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), manifest.New()); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Add(context.Background(), AddOptions{Spec: "git+" + remote + "#:addons/ui"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	m, err := manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		t.Fatalf("load gdpm.json: %v", err)
+	}
+	plugin, ok := m.Plugins["@studio/ui"]
+	if !ok {
+		t.Fatalf("expected @studio/ui in gdpm.json, got %+v", m.Plugins)
+	}
+	if plugin.Repo != "git+"+remote+"#"+sha+":addons/ui" || plugin.Version != "0.2.0" || plugin.Source != manifest.SourceRepo {
+		t.Fatalf("unexpected manifest entry: %+v", plugin)
+	}
+
+	lock, err := manifest.LoadLock(filepath.Join(projectDir, manifest.LockFilename))
+	if err != nil {
+		t.Fatalf("load lock: %v", err)
+	}
+	locked := lock.Plugins["@studio/ui"]
+	if locked.Git != remote || locked.SHA != sha || locked.Subdir != "addons/ui" || locked.Tree == "" || locked.Zipball != "" {
+		t.Fatalf("unexpected lock entry: %+v", locked)
+	}
+
+	if err := os.RemoveAll(filepath.Join(projectDir, "addons")); err != nil {
+		t.Fatalf("remove addons: %v", err)
+	}
+	if err := Install(context.Background(), InstallOptions{Frozen: true}); err != nil {
+		t.Fatalf("frozen install: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "addons", "@studio_ui", "plugin.cfg")); err != nil {
+		t.Fatalf("expected addon to be reinstalled: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "addons", "@studio_ui", "README.md")); !os.IsNotExist(err) {
+		t.Fatalf("expected only the subdirectory to be installed, got %v", err)
+	}
+
+	// The checkout is cached, so the commit installs offline once the remote
+	// is gone.
+	if err := os.RemoveAll(strings.TrimPrefix(remote, "file://")); err != nil {
+		t.Fatalf("remove remote: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(projectDir, "addons")); err != nil {
+		t.Fatalf("remove addons: %v", err)
+	}
+	if err := Install(context.Background(), InstallOptions{Frozen: true, Offline: true}); err != nil {
+		t.Fatalf("offline install: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(projectDir, "addons", "@studio_ui", "plugin.cfg")); err != nil || string(data) != "[plugin]\n" {
+		t.Fatalf("expected addon to be installed from the cache, got %q (%v)", data, err)
+	}
+}
+
+func TestAddAndInstall_FromGitLabURLPinnedToCommit(t *testing.T) {
+	projectDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv(cache.DirEnv, cacheDir)
+
+	sha := strings.Repeat("b", 40)
+	zipPath := filepath.Join(t.TempDir(), "repo.zip")
+	writeTestZipball(t, zipPath, "addons-"+sha, map[string]string{
+		"addons/ui/plugin.cfg": "[plugin]\n",
+	})
+	if err := cache.New(cacheDir, 0).StoreZipball("gitlab.com", "studio%2Fgodot%2Faddons", sha, zipPath); err != nil {
+		t.Fatalf("store zipball: %v", err)
+	}
+
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), manifest.New()); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	spec := "https://gitlab.com/studio/godot/addons/-/tree/" + sha + "/addons/ui"
+	if err := Add(context.Background(), AddOptions{Spec: spec}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	m, err := manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		t.Fatalf("load gdpm.json: %v", err)
+	}
+	if plugin := m.Plugins["@godot/ui"]; plugin.Repo != spec || plugin.Source != manifest.SourceRepo {
+		t.Fatalf("unexpected manifest entry: %+v", m.Plugins)
+	}
+
+	lock, err := manifest.LoadLock(filepath.Join(projectDir, manifest.LockFilename))
+	if err != nil {
+		t.Fatalf("load lock: %v", err)
+	}
+	locked := lock.Plugins["@godot/ui"]
+	if locked.Host != "gitlab.com" || locked.Owner != "studio/godot" || locked.Repo != "addons" || locked.Subdir != "addons/ui" || locked.SHA != sha || locked.Zipball == "" {
+		t.Fatalf("unexpected lock entry: %+v", locked)
+	}
+
+	if err := os.RemoveAll(filepath.Join(projectDir, "addons")); err != nil {
+		t.Fatalf("remove addons: %v", err)
+	}
+	if err := Install(context.Background(), InstallOptions{Frozen: true, Offline: true}); err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "addons", "@godot_ui", "plugin.cfg")); err != nil {
+		t.Fatalf("expected addon to be installed: %v", err)
+	}
+}
+
+func TestAdd_FromGitHubEnterpriseURL(t *testing.T) {
+	projectDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv(cache.DirEnv, cacheDir)
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"registries": []}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv(gdpmdb.ConfigEnv, configPath)
+
+	sha := strings.Repeat("c", 40)
+	zipPath := filepath.Join(t.TempDir(), "repo.zip")
+	writeTestZipball(t, zipPath, "owner-addons-ccccccc", map[string]string{
+		"plugin.cfg": "[plugin]\n",
+	})
+	if err := cache.New(cacheDir, 0).StoreZipball("git.corp.example", "owner%2Faddons", sha, zipPath); err != nil {
+		t.Fatalf("store zipball: %v", err)
+	}
+
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), manifest.New()); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	spec := "https://git.corp.example/owner/addons/tree/" + sha
+	if err := Add(context.Background(), AddOptions{Spec: spec}); err == nil {
+		t.Fatalf("expected an unconfigured host to be rejected")
+	}
+
+	t.Setenv(gdpmdb.GitHubHostsEnv, "git.corp.example")
+	if err := Add(context.Background(), AddOptions{Spec: spec}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	m, err := manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		t.Fatalf("load gdpm.json: %v", err)
+	}
+	if plugin := m.Plugins["@owner/addons"]; plugin.Repo != spec {
+		t.Fatalf("unexpected manifest entry: %+v", m.Plugins)
+	}
+	lock, err := manifest.LoadLock(filepath.Join(projectDir, manifest.LockFilename))
+	if err != nil {
+		t.Fatalf("load lock: %v", err)
+	}
+	if locked := lock.Plugins["@owner/addons"]; locked.Host != "git.corp.example" || locked.Owner != "owner" || locked.SHA != sha {
+		t.Fatalf("unexpected lock entry: %+v", locked)
+	}
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+	"github.com/aviorstudio/gdpm/cli/internal/semver"
+	"github.com/aviorstudio/gdpm/cli/internal/spec"
+)
+
+func resolveRegistryPlugin(ctx context.Context, pluginKey, version string, includePrerelease bool) (gdpmdb.ResolvedPlugin, error) {
+	pkg, err := spec.ParsePackageSpec(pluginKey)
+	if err != nil {
+		return gdpmdb.ResolvedPlugin{}, fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+
+	db, err := openRegistry()
+	if err != nil {
+		return gdpmdb.ResolvedPlugin{}, err
+	}
+	resolved, err := resolvePluginVersion(ctx, db, pkg, version, includePrerelease)
+	if err != nil {
+		return gdpmdb.ResolvedPlugin{}, fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	return resolved, nil
+}
+
+func resolvePluginVersion(ctx context.Context, db gdpmdb.Registry, pkg spec.PackageSpec, version string, includePrerelease bool) (gdpmdb.ResolvedPlugin, error) {
+	version = strings.TrimSpace(version)
+	if !includePrerelease || semver.IsExact(version) {
+		return db.ResolvePlugin(ctx, pkg.Owner, pkg.Repo, version)
+	}
+
+	versions, err := db.ListPluginVersions(ctx, pkg.Owner, pkg.Repo)
+	if err != nil {
+		return gdpmdb.ResolvedPlugin{}, err
+	}
+	selected, ok := gdpmdb.SelectPluginVersion(versions, version, true)
+	if !ok {
+		if version == "" {
+			return gdpmdb.ResolvedPlugin{}, fmt.Errorf("no versions published for %s", pkg.Name())
+		}
+		return gdpmdb.ResolvedPlugin{}, fmt.Errorf("no version matches %s", version)
+	}
+	return db.ResolvePlugin(ctx, pkg.Owner, pkg.Repo, selected.String())
+}
+
+func openRegistry() (gdpmdb.Registry, error) {
+	db, err := gdpmdb.NewDefaultRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("%w: registry configuration: %v", ErrUserInput, err)
+	}
+	return db, nil
+}
+
+func manifestVersionFor(requested, resolved string) string {
+	requested = strings.TrimSpace(requested)
+	if requested == "" {
+		// Without a requested version, accept compatible releases so that
+		// `gdpm update` can move the plugin forward.
+		if _, ok := semver.Parse(resolved); ok {
+			return "^" + resolved
+		}
+		return resolved
+	}
+	if semver.IsExact(requested) {
+		return resolved
+	}
+	// A pre-release picked with --pre may sit outside what the range
+	// normally accepts; pin it so the manifest and lock stay consistent.
+	if !versionSatisfies(requested, resolved) {
+		return resolved
+	}
+	return requested
+}
+
+func isPrerelease(version string) bool {
+	v, ok := semver.Parse(version)
+	return ok && len(v.Pre) > 0
+}
+
+func validateVersionConstraint(version string) error {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return nil
+	}
+	if _, err := semver.ParseConstraint(version); err != nil {
+		return fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	return nil
+}
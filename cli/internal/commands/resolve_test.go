@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+	"github.com/aviorstudio/gdpm/cli/internal/spec"
+)
+
+func TestResolvePluginVersion_Prerelease(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "index.json")
+	index := `{"plugins":{"@alice/tween":{"repo":"https://github.com/alice/tween","versions":[
+  {"version":"1.4.0","sha":"aaa"},
+  {"version":"1.5.0-rc.1","sha":"bbb"},
+  {"version":"2.0.0-beta.3","sha":"ccc"}
+]}}}`
+	if err := os.WriteFile(indexPath, []byte(index), 0o644); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+	db, err := gdpmdb.OpenRegistry(indexPath, "")
+	if err != nil {
+		t.Fatalf("open registry: %v", err)
+	}
+	pkg := spec.PackageSpec{Owner: "alice", Repo: "tween"}
+
+	for _, tc := range []struct {
+		version    string
+		prerelease bool
+		want       string
+	}{
+		{"", false, "1.4.0"},
+		{"", true, "2.0.0-beta.3"},
+		{"^1.0", true, "1.5.0-rc.1"},
+		{"2.0.0-beta.3", false, "2.0.0-beta.3"},
+	} {
+		resolved, err := resolvePluginVersion(context.Background(), db, pkg, tc.version, tc.prerelease)
+		if err != nil {
+			t.Fatalf("%q (pre=%v): %v", tc.version, tc.prerelease, err)
+		}
+		if resolved.Version != tc.want {
+			t.Fatalf("%q (pre=%v): expected %s, got %s", tc.version, tc.prerelease, tc.want, resolved.Version)
+		}
+	}
+}
+
+func TestManifestVersionFor(t *testing.T) {
+	for _, tc := range []struct {
+		requested string
+		resolved  string
+		want      string
+	}{
+		{"", "1.4.0", "^1.4.0"},
+		{"", "2.0.0-beta.3", "^2.0.0-beta.3"},
+		{"", "", ""},
+		{"1.0.0", "1.4.0", "1.4.0"},
+		{"^1.0", "1.4.0", "^1.0"},
+		{"^1.0", "1.5.0-rc.1", "1.5.0-rc.1"},
+	} {
+		if got := manifestVersionFor(tc.requested, tc.resolved); got != tc.want {
+			t.Fatalf("manifestVersionFor(%q, %q) = %q, want %q", tc.requested, tc.resolved, got, tc.want)
+		}
+	}
+}
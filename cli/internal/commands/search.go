@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+)
+
+type SearchOptions struct {
+	Query string
+	Limit int
+	Page  int
+	JSON  bool
+}
+
+type searchResult struct {
+	Name          string `json:"name"`
+	LatestVersion string `json:"latest_version,omitempty"`
+	Repo          string `json:"repo"`
+	Path          string `json:"path,omitempty"`
+}
+
+func Search(ctx context.Context, opts SearchOptions) error {
+	query := strings.TrimSpace(opts.Query)
+	if query == "" {
+		return fmt.Errorf("%w: missing search text", ErrUserInput)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	page := opts.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	db, err := openRegistry()
+	if err != nil {
+		return err
+	}
+	plugins, err := db.SearchPlugins(ctx, query, gdpmdb.SearchOptions{
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	results := make([]searchResult, 0, len(plugins))
+	for _, p := range plugins {
+		results = append(results, searchResult{
+			Name:          p.Name,
+			LatestVersion: p.LatestVersion,
+			Repo:          p.Repo,
+			Path:          p.Path,
+		})
+	}
+
+	if opts.JSON {
+		out, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	if len(results) == 0 {
+		if page > 1 {
+			fmt.Printf("no more plugins matching %q\n", query)
+		} else {
+			fmt.Printf("no plugins matching %q\n", query)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Plugin\tLatest\tRepo\t")
+	for _, r := range results {
+		repo := r.Repo
+		if r.Path != "" {
+			repo += " (" + r.Path + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t\n", r.Name, displayVersion(r.LatestVersion), repo)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(results) == limit {
+		fmt.Printf("more results: gdpm search --limit %d --page %d %q\n", limit, page+1, query)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+)
+
+const stagingDirPrefix = ".gdpm-staging-"
+
+type transaction struct {
+	projectDir string
+	stagingDir string
+	files      []fileSnapshot
+	swaps      []addonSwap
+	seq        int
+	closed     bool
+}
+
+type fileSnapshot struct {
+	path    string
+	data    []byte
+	mode    os.FileMode
+	existed bool
+}
+
+type addonSwap struct {
+	dst    string
+	backup string
+}
+
+func beginTransaction(projectDir string) (*transaction, error) {
+	t := &transaction{projectDir: projectDir}
+	for _, name := range []string{"gdpm.json", manifest.LinkFilename, manifest.LockFilename, "project.godot"} {
+		snap, err := snapshotFile(filepath.Join(projectDir, name))
+		if err != nil {
+			return nil, err
+		}
+		t.files = append(t.files, snap)
+	}
+	return t, nil
+}
+
+func snapshotFile(path string) (fileSnapshot, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fileSnapshot{path: path}, nil
+		}
+		return fileSnapshot{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileSnapshot{}, err
+	}
+	return fileSnapshot{path: path, data: data, mode: info.Mode().Perm(), existed: true}, nil
+}
+
+func (t *transaction) stagePath() (string, error) {
+	if t.stagingDir == "" {
+		// Staged trees live in a hidden directory in the project so they are
+		// swapped in with a rename on the same filesystem; .gdignore keeps
+		// Godot from importing them.
+		dir, err := os.MkdirTemp(t.projectDir, stagingDirPrefix+"*")
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".gdignore"), nil, 0o644); err != nil {
+			_ = os.RemoveAll(dir)
+			return "", err
+		}
+		t.stagingDir = dir
+	}
+	t.seq++
+	return filepath.Join(t.stagingDir, strconv.Itoa(t.seq)), nil
+}
+
+func (t *transaction) copyAddon(src, dst string) error {
+	staged, err := t.stagePath()
+	if err != nil {
+		return err
+	}
+	if err := fsutil.CopyPath(src, staged); err != nil {
+		return err
+	}
+	if info, err := os.Lstat(dst); err == nil && info.IsDir() {
+		if err := fsutil.CopyEditorFiles(dst, staged); err != nil {
+			return err
+		}
+	} else if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return t.swapAddon(staged, dst)
+}
+
+func (t *transaction) symlinkAddon(target, dst string) error {
+	staged, err := t.stagePath()
+	if err != nil {
+		return err
+	}
+	if err := fsutil.SymlinkDir(target, staged); err != nil {
+		return err
+	}
+	return t.swapAddon(staged, dst)
+}
+
+func (t *transaction) removeAddon(dst string) error {
+	return t.swapAddon("", dst)
+}
+
+func (t *transaction) swapAddon(staged, dst string) error {
+	swap := addonSwap{dst: dst}
+	if _, err := os.Lstat(dst); err == nil {
+		backup, err := t.stagePath()
+		if err != nil {
+			return err
+		}
+		if err := os.Rename(dst, backup); err != nil {
+			return err
+		}
+		swap.backup = backup
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	t.swaps = append(t.swaps, swap)
+
+	if staged == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(staged, dst)
+}
+
+func (t *transaction) commit() error {
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	if t.stagingDir == "" {
+		return nil
+	}
+	return os.RemoveAll(t.stagingDir)
+}
+
+func (t *transaction) rollback() {
+	if t.closed {
+		return
+	}
+	t.closed = true
+
+	var failed []error
+	for i := len(t.swaps) - 1; i >= 0; i-- {
+		swap := t.swaps[i]
+		if err := fsutil.RemoveAll(swap.dst); err != nil {
+			failed = append(failed, err)
+			continue
+		}
+		if swap.backup != "" {
+			if err := os.Rename(swap.backup, swap.dst); err != nil {
+				failed = append(failed, err)
+			}
+		}
+	}
+	for _, snap := range t.files {
+		if err := snap.restore(); err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) == 0 && t.stagingDir != "" {
+		if err := os.RemoveAll(t.stagingDir); err != nil {
+			failed = append(failed, err)
+		}
+	}
+
+	for _, err := range failed {
+		fmt.Fprintf(os.Stderr, "warning: rollback incomplete: %v\n", err)
+	}
+	if len(failed) != 0 && t.stagingDir != "" {
+		fmt.Fprintf(os.Stderr, "warning: previous addon directories are kept in %s\n", t.stagingDir)
+	}
+}
+
+func (s fileSnapshot) restore() error {
+	if !s.existed {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if current, err := os.ReadFile(s.path); err == nil && bytes.Equal(current, s.data) {
+		return nil
+	}
+	return fsutil.WriteFileAtomic(s.path, s.data, s.mode)
+}
@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTransactionFixture(t *testing.T) string {
+	t.Helper()
+	projectDir := t.TempDir()
+	files := map[string]string{
+		"gdpm.json":                      "{\"plugins\":{}}\n",
+		"project.godot":                  "[editor_plugins]\n",
+		"addons/a_ui/plugin.cfg":         "old ui\n",
+		"addons/b_tween/plugin.cfg":      "tween\n",
+		"src/a_ui/plugin.cfg":            "new ui\n",
+		"src/a_ui/scripts/new_script.gd": "extends Node\n",
+	}
+	for name, content := range files {
+		p := filepath.Join(projectDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	return projectDir
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func stagingDirs(t *testing.T, projectDir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(projectDir, stagingDirPrefix+"*"))
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	return matches
+}
+
+func TestTransaction_RollbackRestoresAddonsAndFiles(t *testing.T) {
+	projectDir := writeTransactionFixture(t)
+	addonsDir := filepath.Join(projectDir, "addons")
+
+	tx, err := beginTransaction(projectDir)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := tx.copyAddon(filepath.Join(projectDir, "src", "a_ui"), filepath.Join(addonsDir, "a_ui")); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if err := tx.copyAddon(filepath.Join(projectDir, "src", "a_ui"), filepath.Join(addonsDir, "c_new")); err != nil {
+		t.Fatalf("copy new: %v", err)
+	}
+	if err := tx.removeAddon(filepath.Join(addonsDir, "b_tween")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "gdpm.json"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, "gdpm.lock"), []byte("{}\n"), 0o644); err != nil {
+		t.Fatalf("write gdpm.lock: %v", err)
+	}
+	if got := readTestFile(t, filepath.Join(addonsDir, "a_ui", "plugin.cfg")); got != "new ui\n" {
+		t.Fatalf("expected staged addon to be swapped in, got %q", got)
+	}
+
+	tx.rollback()
+
+	if got := readTestFile(t, filepath.Join(addonsDir, "a_ui", "plugin.cfg")); got != "old ui\n" {
+		t.Fatalf("expected a_ui to be restored, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(addonsDir, "a_ui", "scripts")); !os.IsNotExist(err) {
+		t.Fatalf("expected staged files to be gone from a_ui, got err=%v", err)
+	}
+	if got := readTestFile(t, filepath.Join(addonsDir, "b_tween", "plugin.cfg")); got != "tween\n" {
+		t.Fatalf("expected b_tween to be restored, got %q", got)
+	}
+	if _, err := os.Lstat(filepath.Join(addonsDir, "c_new")); !os.IsNotExist(err) {
+		t.Fatalf("expected c_new to be removed, got err=%v", err)
+	}
+	if got := readTestFile(t, filepath.Join(projectDir, "gdpm.json")); got != "{\"plugins\":{}}\n" {
+		t.Fatalf("expected gdpm.json to be restored, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, "gdpm.lock")); !os.IsNotExist(err) {
+		t.Fatalf("expected gdpm.lock created during the transaction to be removed, got err=%v", err)
+	}
+	if dirs := stagingDirs(t, projectDir); len(dirs) != 0 {
+		t.Fatalf("expected staging directory to be removed, got %v", dirs)
+	}
+}
+
+func TestTransaction_CommitKeepsChanges(t *testing.T) {
+	projectDir := writeTransactionFixture(t)
+	addonsDir := filepath.Join(projectDir, "addons")
+
+	tx, err := beginTransaction(projectDir)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if err := tx.copyAddon(filepath.Join(projectDir, "src", "a_ui"), filepath.Join(addonsDir, "a_ui")); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if err := tx.removeAddon(filepath.Join(addonsDir, "b_tween")); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := tx.commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	tx.rollback()
+
+	if got := readTestFile(t, filepath.Join(addonsDir, "a_ui", "plugin.cfg")); got != "new ui\n" {
+		t.Fatalf("expected a_ui to keep the new tree, got %q", got)
+	}
+	if _, err := os.Lstat(filepath.Join(addonsDir, "b_tween")); !os.IsNotExist(err) {
+		t.Fatalf("expected b_tween to stay removed, got err=%v", err)
+	}
+	if dirs := stagingDirs(t, projectDir); len(dirs) != 0 {
+		t.Fatalf("expected staging directory to be removed, got %v", dirs)
+	}
+}
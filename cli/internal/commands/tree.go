@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aviorstudio/gdpm/cli/internal/project"
+)
+
+type TreeOptions struct{}
+
+func Tree(ctx context.Context, opts TreeOptions) error {
+	_ = ctx
+	_ = opts
+
+	startDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	projectDir, ok := project.FindManifestDir(startDir)
+	if !ok {
+		return fmt.Errorf("%w: no gdpm.json found (run `gdpm init`)", ErrUserInput)
+	}
+
+	g, err := loadDependencyGraph(projectDir)
+	if err != nil {
+		return err
+	}
+	if len(g.roots) == 0 {
+		fmt.Println("no plugins in gdpm.json")
+		return nil
+	}
+	return renderDependencyTree(os.Stdout, g)
+}
@@ -9,15 +9,14 @@ import (
 	"strings"
 
 	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
-	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
-	"github.com/aviorstudio/gdpm/cli/internal/githubapi"
 	"github.com/aviorstudio/gdpm/cli/internal/manifest"
 	"github.com/aviorstudio/gdpm/cli/internal/project"
 	"github.com/aviorstudio/gdpm/cli/internal/spec"
 )
 
 type UnlinkOptions struct {
-	Spec string
+	Spec    string
+	Offline bool
 }
 
 func Unlink(ctx context.Context, opts UnlinkOptions) error {
@@ -39,6 +38,25 @@ func Unlink(ctx context.Context, opts UnlinkOptions) error {
 		return fmt.Errorf("%w: no gdpm.json found (run `gdpm init`)", ErrUserInput)
 	}
 
+	unlock, err := lockProject(ctx, projectDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tx, err := beginTransaction(projectDir)
+	if err != nil {
+		return err
+	}
+	defer tx.rollback()
+
+	if err := unlinkPlugin(ctx, tx, projectDir, specInput, opts.Offline); err != nil {
+		return err
+	}
+	return tx.commit()
+}
+
+func unlinkPlugin(ctx context.Context, tx *transaction, projectDir, specInput string, offline bool) error {
 	manifestPath := filepath.Join(projectDir, "gdpm.json")
 	m, err := manifest.Load(manifestPath)
 	if err != nil {
@@ -77,6 +95,26 @@ func Unlink(ctx context.Context, opts UnlinkOptions) error {
 		plugin.Link.Enabled = false
 	}
 
+	if pluginPath := strings.TrimSpace(plugin.Path); pluginPath != "" {
+		if _, err := installPathPlugin(tx, projectDir, addonDirName, pluginPath); err != nil {
+			return err
+		}
+		m = manifest.UpsertPlugin(m, pluginKey, plugin)
+		if err := manifest.Save(manifestPath, m); err != nil {
+			return err
+		}
+		projectGodotPath := filepath.Join(projectDir, "project.godot")
+		if _, err := os.Stat(projectGodotPath); err == nil && linkedAbs != "" {
+			if err := disableEditorPluginAliases(projectGodotPath, projectDir, m, pluginKey, addonDirName, linkedAbs); err != nil {
+				return err
+			}
+		} else if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		fmt.Printf("unlinked %s\n", pluginKey)
+		return nil
+	}
+
 	if strings.TrimSpace(plugin.Repo) == "" {
 		projectGodotPath := filepath.Join(projectDir, "project.godot")
 		if _, err := os.Stat(projectGodotPath); err == nil {
@@ -97,7 +135,7 @@ func Unlink(ctx context.Context, opts UnlinkOptions) error {
 			return err
 		}
 
-		if err := fsutil.RemoveAll(dst); err != nil {
+		if err := tx.removeAddon(dst); err != nil {
 			return err
 		}
 
@@ -109,7 +147,7 @@ func Unlink(ctx context.Context, opts UnlinkOptions) error {
 		return nil
 	}
 
-	ghOwner, ghRepo, ref, repoSubdir, err := gdpmdb.ParseGitHubTreeURLWithPath(plugin.Repo)
+	locked, _, err := lockedSourceForPlugin(plugin)
 	if err != nil {
 		return err
 	}
@@ -120,51 +158,22 @@ func Unlink(ctx context.Context, opts UnlinkOptions) error {
 	}
 	defer os.RemoveAll(tmpDir)
 
-	zipPath := filepath.Join(tmpDir, "repo.zip")
-	gh := githubapi.NewClient(os.Getenv("GITHUB_TOKEN"))
-	if err := gh.DownloadZipball(ctx, ghOwner, ghRepo, ref, zipPath); err != nil {
-		return err
-	}
-
-	extractDir := filepath.Join(tmpDir, "extract")
-	rootDir, err := fsutil.ExtractZip(zipPath, extractDir)
+	fetcher, err := newPackageFetcher(offlineMode(offline))
 	if err != nil {
 		return err
 	}
-
-	pkgRootDir, err := repoSubdirRoot(rootDir, repoSubdir)
+	fetched, err := fetcher.fetchLockedPackage(ctx, tmpDir, locked, addonDirName)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrUserInput, err)
-	}
-
-	if ok, err := pluginCfgExistsAtDirRoot(pkgRootDir); err != nil {
-		return fmt.Errorf("%w: %v", ErrUserInput, err)
-	} else if !ok {
-		expected := "res://" + path.Join("addons", addonDirName, "plugin.cfg")
-		if strings.TrimSpace(repoSubdir) != "" {
-			return fmt.Errorf("%w: package is missing plugin.cfg at %s in repository (expected to install it to %s)", ErrUserInput, repoSubdir, expected)
-		}
-		return fmt.Errorf("%w: package is missing plugin.cfg at repository root (expected to install it to %s)", ErrUserInput, expected)
-	}
-
-	localAddonsDir := filepath.Join(projectDir, "addons")
-	if err := os.MkdirAll(localAddonsDir, 0o755); err != nil {
-		return err
-	}
-
-	if err := fsutil.RemoveAll(dst); err != nil {
 		return err
 	}
 
-	if err := fsutil.CopyPath(pkgRootDir, dst); err != nil {
+	if err := tx.copyAddon(fetched.rootDir, dst); err != nil {
 		return err
 	}
 
 	if ok, err := pluginCfgExistsAtDirRoot(dst); err != nil {
-		_ = fsutil.RemoveAll(dst)
 		return fmt.Errorf("%w: %v", ErrUserInput, err)
 	} else if !ok {
-		_ = fsutil.RemoveAll(dst)
 		return fmt.Errorf("%w: installed addon is missing plugin.cfg at %s", ErrUserInput, filepath.Join(dst, "plugin.cfg"))
 	}
 
@@ -173,6 +182,16 @@ func Unlink(ctx context.Context, opts UnlinkOptions) error {
 		return err
 	}
 
+	treeHash, err := fsutil.HashTree(fetched.rootDir)
+	if err != nil {
+		return err
+	}
+	locked.Zipball = fetched.zipballDigest
+	locked.Tree = treeHash
+	if err := setProjectLockedPlugin(projectDir, pluginKey, &locked); err != nil {
+		return err
+	}
+
 	projectGodotPath := filepath.Join(projectDir, "project.godot")
 	if _, err := os.Stat(projectGodotPath); err == nil {
 		pluginCfgResPath := "res://" + path.Join("addons", addonDirName, "plugin.cfg")
@@ -6,16 +6,17 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/aviorstudio/gdpm/cli/internal/manifest"
 	"github.com/aviorstudio/gdpm/cli/internal/project"
 )
 
-type UnlinkAllOptions struct{}
+type UnlinkAllOptions struct {
+	Offline bool
+}
 
 func UnlinkAll(ctx context.Context, opts UnlinkAllOptions) error {
-	_ = opts
-
 	startDir, err := os.Getwd()
 	if err != nil {
 		return err
@@ -26,6 +27,12 @@ func UnlinkAll(ctx context.Context, opts UnlinkAllOptions) error {
 		return fmt.Errorf("%w: no gdpm.json found (run `gdpm init`)", ErrUserInput)
 	}
 
+	unlock, err := lockProject(ctx, projectDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	manifestPath := filepath.Join(projectDir, "gdpm.json")
 	m, err := manifest.Load(manifestPath)
 	if err != nil {
@@ -41,11 +48,37 @@ func UnlinkAll(ctx context.Context, opts UnlinkAllOptions) error {
 	}
 	sort.Strings(pluginKeys)
 
+	if offlineMode(opts.Offline) {
+		fetcher, err := newPackageFetcher(true)
+		if err != nil {
+			return err
+		}
+		var missing []string
+		for _, pluginKey := range pluginKeys {
+			locked, ok, err := lockedSourceForPlugin(m.Plugins[pluginKey])
+			if err != nil || !ok {
+				continue
+			}
+			if fetcher.missingArtifact(locked) {
+				missing = append(missing, fmt.Sprintf("  %s (%s@%s)", pluginKey, archiveLabel(locked), locked.SHA))
+			}
+		}
+		if len(missing) != 0 {
+			return fmt.Errorf("%w: offline and missing from the download cache at %s:\n%s", ErrUserInput, fetcher.cache.Dir(), strings.Join(missing, "\n"))
+		}
+	}
+
+	tx, err := beginTransaction(projectDir)
+	if err != nil {
+		return err
+	}
+	defer tx.rollback()
+
 	for _, pluginKey := range pluginKeys {
-		if err := Unlink(ctx, UnlinkOptions{Spec: pluginKey}); err != nil {
+		if err := unlinkPlugin(ctx, tx, projectDir, pluginKey, opts.Offline); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return tx.commit()
 }
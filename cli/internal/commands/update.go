@@ -0,0 +1,284 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+	"github.com/aviorstudio/gdpm/cli/internal/project"
+	"github.com/aviorstudio/gdpm/cli/internal/semver"
+	"github.com/aviorstudio/gdpm/cli/internal/spec"
+)
+
+type UpdateOptions struct {
+	Specs      []string
+	Prerelease bool
+}
+
+type updatedPlugin struct {
+	pluginKey  string
+	oldVersion string
+	oldSHA     string
+	newVersion string
+	newSHA     string
+	linked     bool
+}
+
+func Update(ctx context.Context, opts UpdateOptions) error {
+	startDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	projectDir, ok := project.FindManifestDir(startDir)
+	if !ok {
+		return fmt.Errorf("%w: no gdpm.json found (run `gdpm init`)", ErrUserInput)
+	}
+
+	unlock, err := lockProject(ctx, projectDir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	manifestPath := filepath.Join(projectDir, "gdpm.json")
+	m, err := manifest.Load(manifestPath)
+	if err != nil {
+		return err
+	}
+	lock, _, err := loadProjectLock(projectDir)
+	if err != nil {
+		return err
+	}
+
+	pluginKeys, err := updatePluginKeys(m, opts.Specs)
+	if err != nil {
+		return err
+	}
+	if len(pluginKeys) == 0 {
+		return nil
+	}
+
+	tx, err := beginTransaction(projectDir)
+	if err != nil {
+		return err
+	}
+	defer tx.rollback()
+
+	projectGodotPath := filepath.Join(projectDir, "project.godot")
+	hasProjectGodot := false
+	if _, err := os.Stat(projectGodotPath); err == nil {
+		hasProjectGodot = true
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "gdpm-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	fetcher, err := newPackageFetcher(false)
+	if err != nil {
+		return err
+	}
+
+	var updated []updatedPlugin
+	for i, pluginKey := range pluginKeys {
+		plugin := m.Plugins[pluginKey]
+		old, _, err := lockedSourceForPlugin(plugin)
+		if err != nil {
+			return fmt.Errorf("%w: invalid repo for %s: %v", ErrUserInput, pluginKey, err)
+		}
+		oldVersion := resolvedPluginVersion(lock, pluginKey, plugin)
+
+		var resolved gdpmdb.ResolvedPlugin
+		if pluginFromRepo(plugin) {
+			resolved, err = resolveRepoSource(ctx, old, "")
+		} else {
+			constraint := strings.TrimSpace(plugin.Version)
+			if semver.IsExact(constraint) {
+				fmt.Printf("kept %s (pinned in gdpm.json; run `gdpm add %s@<version>` to move it)\n", pluginWithVersion(pluginKey, constraint), pluginKey)
+				continue
+			}
+			// Stay on the pre-release track once a plugin is on it, rather than
+			// "updating" back to an older stable release.
+			includePrerelease := opts.Prerelease || isPrerelease(oldVersion)
+			resolved, err = resolveRegistryPlugin(ctx, pluginKey, constraint, includePrerelease)
+		}
+		if err != nil {
+			return err
+		}
+
+		locked := lockedSourceForResolved(resolved)
+		if locked.SameCommit(old) {
+			if oldVersion == "" {
+				if err := setProjectLockedPlugin(projectDir, pluginKey, &locked); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		isLinked := pluginLinkEnabled(plugin)
+		if !isLinked {
+			addonDirName, err := addonDirNameForPluginKey(pluginKey)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrUserInput, err)
+			}
+
+			pkgTmpDir := filepath.Join(tmpDir, fmt.Sprintf("pkg-%d", i))
+			if err := os.MkdirAll(pkgTmpDir, 0o755); err != nil {
+				return err
+			}
+			fetched, err := fetcher.fetchLockedPackage(ctx, pkgTmpDir, locked, addonDirName)
+			if err != nil {
+				return err
+			}
+
+			dst := filepath.Join(projectDir, "addons", addonDirName)
+			if err := tx.copyAddon(fetched.rootDir, dst); err != nil {
+				return err
+			}
+			if ok, err := pluginCfgExistsAtDirRoot(dst); err != nil {
+				return fmt.Errorf("%w: %v", ErrUserInput, err)
+			} else if !ok {
+				return fmt.Errorf("%w: installed addon is missing plugin.cfg at %s", ErrUserInput, filepath.Join(dst, "plugin.cfg"))
+			}
+
+			treeHash, err := fsutil.HashTree(fetched.rootDir)
+			if err != nil {
+				return err
+			}
+			locked.Zipball = fetched.zipballDigest
+			locked.Tree = treeHash
+
+			if hasProjectGodot {
+				pluginCfgResPath := "res://" + path.Join("addons", addonDirName, "plugin.cfg")
+				enabled, err := project.SetEditorPluginEnabled(projectGodotPath, pluginCfgResPath, true)
+				if err != nil {
+					return err
+				}
+				if enabled {
+					fmt.Printf("enabled %s\n", pluginCfgResPath)
+				}
+			}
+		}
+
+		plugin.Repo = repoURLForResolved(resolved)
+		if pluginFromRepo(plugin) {
+			plugin.Version = resolved.Version
+		} else {
+			plugin.Version = manifestVersionFor(plugin.Version, resolved.Version)
+		}
+		m = manifest.UpsertPlugin(m, pluginKey, plugin)
+		if err := manifest.Save(manifestPath, m); err != nil {
+			return err
+		}
+		if err := setProjectLockedPlugin(projectDir, pluginKey, &locked); err != nil {
+			return err
+		}
+
+		updated = append(updated, updatedPlugin{
+			pluginKey:  pluginKey,
+			oldVersion: oldVersion,
+			oldSHA:     old.SHA,
+			newVersion: resolved.Version,
+			newSHA:     resolved.SHA,
+			linked:     isLinked,
+		})
+	}
+
+	if len(updated) == 0 {
+		fmt.Println("all plugins are up to date")
+		return tx.commit()
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Plugin\tFrom\tTo\t")
+	for _, u := range updated {
+		note := ""
+		if u.linked {
+			note = "(linked)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", u.pluginKey, versionAndSHA(u.oldVersion, u.oldSHA), versionAndSHA(u.newVersion, u.newSHA), note)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := syncDependencies(ctx, tx, projectDir); err != nil {
+		return err
+	}
+	return tx.commit()
+}
+
+func updatePluginKeys(m manifest.Manifest, specs []string) ([]string, error) {
+	if len(specs) == 0 {
+		pluginKeys := make([]string, 0, len(m.Plugins))
+		for key, plugin := range m.Plugins {
+			if strings.TrimSpace(plugin.Repo) == "" {
+				continue
+			}
+			pluginKeys = append(pluginKeys, key)
+		}
+		sort.Strings(pluginKeys)
+		return pluginKeys, nil
+	}
+
+	seen := map[string]bool{}
+	pluginKeys := make([]string, 0, len(specs))
+	for _, specInput := range specs {
+		specInput = strings.TrimSpace(specInput)
+		if specInput == "" {
+			return nil, fmt.Errorf("%w: missing plugin spec", ErrUserInput)
+		}
+		if !strings.HasPrefix(specInput, "@") {
+			specInput = "@" + specInput
+		}
+		pkg, err := spec.ParsePackageSpec(specInput)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUserInput, err)
+		}
+		if pkg.Version != "" {
+			return nil, fmt.Errorf("%w: update does not take a version (use `gdpm add %s@%s`)", ErrUserInput, pkg.Name(), pkg.Version)
+		}
+		plugin, ok := m.Plugins[pkg.Name()]
+		if !ok {
+			return nil, fmt.Errorf("%w: plugin not found in gdpm.json: %s", ErrUserInput, pkg.Name())
+		}
+		if strings.TrimSpace(plugin.Repo) == "" {
+			return nil, fmt.Errorf("%w: plugin has no repo to update from: %s", ErrUserInput, pkg.Name())
+		}
+		if seen[pkg.Name()] {
+			continue
+		}
+		seen[pkg.Name()] = true
+		pluginKeys = append(pluginKeys, pkg.Name())
+	}
+	return pluginKeys, nil
+}
+
+func versionAndSHA(version, sha string) string {
+	version = displayVersion(version)
+	if sha = shortSHA(sha); sha != "" {
+		return version + " (" + sha + ")"
+	}
+	return version
+}
+
+func shortSHA(sha string) string {
+	sha = strings.TrimSpace(sha)
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aviorstudio/gdpm/cli/internal/cache"
+	"github.com/aviorstudio/gdpm/cli/internal/gdpmdb"
+	"github.com/aviorstudio/gdpm/cli/internal/manifest"
+)
+
+func TestUpdatePluginKeys(t *testing.T) {
+	m := manifest.New()
+	m = manifest.UpsertPlugin(m, "@user/b", manifest.Plugin{Repo: "https://github.com/owner/b/tree/abc"})
+	m = manifest.UpsertPlugin(m, "@user/a", manifest.Plugin{Repo: "https://github.com/owner/a/tree/abc"})
+	m = manifest.UpsertPlugin(m, "@user/local", manifest.Plugin{})
+
+	got, err := updatePluginKeys(m, nil)
+	if err != nil {
+		t.Fatalf("updatePluginKeys: %v", err)
+	}
+	if want := []string{"@user/a", "@user/b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	got, err = updatePluginKeys(m, []string{"user/b", "@user/b"})
+	if err != nil {
+		t.Fatalf("updatePluginKeys: %v", err)
+	}
+	if want := []string{"@user/b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for _, specs := range [][]string{
+		{"@user/missing"},
+		{"@user/local"},
+		{"@user/a@1.0.0"},
+	} {
+		if _, err := updatePluginKeys(m, specs); !errors.Is(err, ErrUserInput) {
+			t.Fatalf("%v: expected user input error, got %v", specs, err)
+		}
+	}
+}
+
+func TestUpdate_MovesPluginAddedWithoutVersion(t *testing.T) {
+	projectDir := t.TempDir()
+	cacheDir := t.TempDir()
+	t.Setenv(cache.DirEnv, cacheDir)
+
+	oldSHA := strings.Repeat("1", 40)
+	newSHA := strings.Repeat("2", 40)
+	workDir := t.TempDir()
+	c := cache.New(cacheDir, 0)
+	for _, sha := range []string{oldSHA, newSHA} {
+		zipPath := filepath.Join(workDir, sha[:7]+".zip")
+		writeTestZipball(t, zipPath, "studio-tween-"+sha[:7], map[string]string{
+			"plugin.cfg": "[plugin]\n",
+			"tween.gd":   "# " + sha[:7] + "\n",
+		})
+		if err := c.StoreZipball("studio", "tween", sha, zipPath); err != nil {
+			t.Fatalf("store zipball: %v", err)
+		}
+	}
+
+	indexPath := filepath.Join(workDir, "index.json")
+	writeIndex := func(versions string) {
+		t.Helper()
+		index := `{"plugins":{"@studio/tween":{"repo":"https://github.com/studio/tween","versions":[` + versions + `]}}}`
+		if err := os.WriteFile(indexPath, []byte(index), 0o644); err != nil {
+			t.Fatalf("write index: %v", err)
+		}
+	}
+	writeIndex(`{"version":"1.0.0","sha":"` + oldSHA + `"}`)
+	t.Setenv(gdpmdb.RegistryEnv, indexPath)
+
+	if err := manifest.Save(filepath.Join(projectDir, "gdpm.json"), manifest.New()); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	oldWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	defer func() {
+		_ = os.Chdir(oldWd)
+	}()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+
+	if err := Add(context.Background(), AddOptions{Spec: "@studio/tween"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	writeIndex(`{"version":"1.0.0","sha":"` + oldSHA + `"},{"version":"1.1.0","sha":"` + newSHA + `"}`)
+	if err := Update(context.Background(), UpdateOptions{}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	m, err := manifest.Load(filepath.Join(projectDir, "gdpm.json"))
+	if err != nil {
+		t.Fatalf("load gdpm.json: %v", err)
+	}
+	if plugin := m.Plugins["@studio/tween"]; plugin.Version != "^1.0.0" || !strings.Contains(plugin.Repo, newSHA) {
+		t.Fatalf("expected update to move the plugin within ^1.0.0, got %+v", plugin)
+	}
+	lock, err := manifest.LoadLock(filepath.Join(projectDir, manifest.LockFilename))
+	if err != nil {
+		t.Fatalf("load lock: %v", err)
+	}
+	if locked := lock.Plugins["@studio/tween"]; locked.Version != "1.1.0" || locked.SHA != newSHA {
+		t.Fatalf("expected lock to record 1.1.0, got %+v", locked)
+	}
+	data, err := os.ReadFile(filepath.Join(projectDir, "addons", "@studio_tween", "tween.gd"))
+	if err != nil || string(data) != "# "+newSHA[:7]+"\n" {
+		t.Fatalf("expected the addon to be swapped, got %q (err=%v)", data, err)
+	}
+}
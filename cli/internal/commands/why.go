@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aviorstudio/gdpm/cli/internal/project"
+	"github.com/aviorstudio/gdpm/cli/internal/spec"
+)
+
+type WhyOptions struct {
+	Spec string
+}
+
+func Why(ctx context.Context, opts WhyOptions) error {
+	_ = ctx
+
+	specInput := strings.TrimSpace(opts.Spec)
+	if specInput != "" && !strings.HasPrefix(specInput, "@") {
+		specInput = "@" + specInput
+	}
+	pkg, err := spec.ParsePackageSpec(specInput)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUserInput, err)
+	}
+	if pkg.Version != "" {
+		return fmt.Errorf("%w: why does not take a version (use @username/plugin)", ErrUserInput)
+	}
+
+	startDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	projectDir, ok := project.FindManifestDir(startDir)
+	if !ok {
+		return fmt.Errorf("%w: no gdpm.json found (run `gdpm init`)", ErrUserInput)
+	}
+
+	g, err := loadDependencyGraph(projectDir)
+	if err != nil {
+		return err
+	}
+	node, ok := g.nodes[pkg.Name()]
+	if !ok {
+		return fmt.Errorf("%w: %s is not required by this project", ErrUserInput, pkg.Name())
+	}
+
+	fmt.Println(node.label())
+	for _, chain := range dependencyChains(g, pkg.Name()) {
+		line := "gdpm.json > " + strings.Join(chain, " > ")
+		if len(chain) > 1 {
+			parent := g.nodes[chain[len(chain)-2]]
+			if constraint := parent.deps[pkg.Name()]; constraint != "" {
+				line += " (" + constraint + ")"
+			}
+		}
+		fmt.Printf("  %s\n", line)
+	}
+	return nil
+}
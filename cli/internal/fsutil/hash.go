@@ -0,0 +1,206 @@
+package fsutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const hashPrefix = "sha256:"
+
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hashPrefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func HashTree(dir string) (string, error) {
+	return hashTree(dir, nil)
+}
+
+// HashInstalledTree hashes an installed addon like HashTree, but leaves out
+// the editor files (see IsEditorFile) that packageDir does not ship, so
+// opening the project in Godot does not count as a change. Editor files the
+// package ships are hashed like any other file. An empty packageDir leaves
+// out every editor file.
+func HashInstalledTree(dir, packageDir string) (string, error) {
+	shipped := map[string]string{}
+	if packageDir != "" {
+		var err error
+		if shipped, err = treeFileHashes(packageDir, nil); err != nil {
+			return "", err
+		}
+	}
+	return hashTree(dir, func(rel string) bool {
+		_, ok := shipped[rel]
+		return !ok && IsEditorFile(rel)
+	})
+}
+
+func IsEditorFile(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, part := range strings.Split(rel, "/") {
+		if part == ".godot" {
+			return true
+		}
+	}
+	return strings.HasSuffix(rel, ".import") || strings.HasSuffix(rel, ".uid")
+}
+
+func hashTree(dir string, skip func(rel string) bool) (string, error) {
+	files, err := treeFileHashes(dir, skip)
+	if err != nil {
+		return "", err
+	}
+
+	rels := make([]string, 0, len(files))
+	for rel := range files {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+
+	h := sha256.New()
+	for _, rel := range rels {
+		fmt.Fprintf(h, "%s %s\n", files[rel], rel)
+	}
+	return hashPrefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type TreeDiff struct {
+	Modified []string
+	Missing  []string
+	Extra    []string
+}
+
+func (d TreeDiff) Empty() bool {
+	return len(d.Modified) == 0 && len(d.Missing) == 0 && len(d.Extra) == 0
+}
+
+func DiffTrees(wantDir, gotDir string) (TreeDiff, error) {
+	want, err := treeFileHashes(wantDir, nil)
+	if err != nil {
+		return TreeDiff{}, err
+	}
+	got, err := treeFileHashes(gotDir, nil)
+	if err != nil {
+		return TreeDiff{}, err
+	}
+
+	var diff TreeDiff
+	for rel, sum := range want {
+		gotSum, ok := got[rel]
+		switch {
+		case !ok:
+			diff.Missing = append(diff.Missing, rel)
+		case gotSum != sum:
+			diff.Modified = append(diff.Modified, rel)
+		}
+	}
+	for rel := range got {
+		// Editor files that wantDir does not ship were generated by Godot.
+		if _, ok := want[rel]; !ok && !IsEditorFile(rel) {
+			diff.Extra = append(diff.Extra, rel)
+		}
+	}
+	sort.Strings(diff.Modified)
+	sort.Strings(diff.Missing)
+	sort.Strings(diff.Extra)
+	return diff, nil
+}
+
+func CopyEditorFiles(fromDir, toDir string) error {
+	// Godot writes <file>.import and <file>.uid next to assets and scripts.
+	// Keeping them when an addon is replaced keeps the UIDs that scenes
+	// refer to.
+	return filepath.WalkDir(fromDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".godot" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !IsEditorFile(d.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(fromDir, p)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(toDir, rel)
+		source := strings.TrimSuffix(strings.TrimSuffix(dst, ".import"), ".uid")
+		if _, err := os.Lstat(source); err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if _, err := os.Lstat(dst); err == nil {
+			return nil
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		return CopyPath(p, dst)
+	})
+}
+
+func treeFileHashes(dir string, skip func(rel string) bool) (map[string]string, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", dir)
+	}
+
+	files := map[string]string{}
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if skip != nil && skip(rel) {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to hash symlink: %s", p)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		sum, err := HashFile(p)
+		if err != nil {
+			return err
+		}
+		files[rel] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
@@ -0,0 +1,186 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHashTree_StableAcrossCopies(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "plugin.cfg"), []byte("[plugin]\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "a.gd"), []byte("extends Node\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	dst := filepath.Join(dir, "dst")
+	if err := CopyPath(src, dst); err != nil {
+		t.Fatalf("CopyPath: %v", err)
+	}
+
+	a, err := HashTree(src)
+	if err != nil {
+		t.Fatalf("HashTree(src): %v", err)
+	}
+	b, err := HashTree(dst)
+	if err != nil {
+		t.Fatalf("HashTree(dst): %v", err)
+	}
+	if a != b {
+		t.Fatalf("expected equal hashes, got %q and %q", a, b)
+	}
+
+	if err := os.WriteFile(filepath.Join(dst, "sub", "a.gd"), []byte("extends Node2D\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	c, err := HashTree(dst)
+	if err != nil {
+		t.Fatalf("HashTree(dst): %v", err)
+	}
+	if c == a {
+		t.Fatalf("expected hash to change after modifying a file")
+	}
+}
+
+func TestDiffTrees(t *testing.T) {
+	dir := t.TempDir()
+	want := filepath.Join(dir, "want")
+	got := filepath.Join(dir, "got")
+	for root, files := range map[string]map[string]string{
+		want: {"plugin.cfg": "[plugin]\n", "a.gd": "extends Node\n", "icons/icon.svg": "<svg/>"},
+		got:  {"plugin.cfg": "[plugin]\n", "a.gd": "extends Node2D\n", "notes.txt": "todo"},
+	} {
+		for rel, content := range files {
+			p := filepath.Join(root, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+				t.Fatalf("mkdir: %v", err)
+			}
+			if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+		}
+	}
+
+	diff, err := DiffTrees(want, got)
+	if err != nil {
+		t.Fatalf("DiffTrees: %v", err)
+	}
+	expected := TreeDiff{
+		Modified: []string{"a.gd"},
+		Missing:  []string{"icons/icon.svg"},
+		Extra:    []string{"notes.txt"},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, diff)
+	}
+
+	same, err := DiffTrees(want, want)
+	if err != nil {
+		t.Fatalf("DiffTrees: %v", err)
+	}
+	if !same.Empty() {
+		t.Fatalf("expected no differences, got %+v", same)
+	}
+}
+
+func TestHashInstalledTree_IgnoresOnlyUnshippedEditorFiles(t *testing.T) {
+	dir := t.TempDir()
+	pkg := filepath.Join(dir, "pkg")
+	for rel, content := range map[string]string{
+		"plugin.cfg":     "[plugin]\n",
+		"a.gd":           "extends Node\n",
+		"icon.svg":       "<svg/>",
+		"shipped.gd.uid": "uid://s1\n",
+	} {
+		writeTreeFile(t, pkg, rel, content)
+	}
+	pinned, err := HashTree(pkg)
+	if err != nil {
+		t.Fatalf("HashTree: %v", err)
+	}
+	installed := filepath.Join(dir, "installed")
+	if err := CopyPath(pkg, installed); err != nil {
+		t.Fatalf("CopyPath: %v", err)
+	}
+
+	for rel, content := range map[string]string{
+		"a.gd.uid":               "uid://b1\n",
+		"icon.svg.import":        "[remap]\n",
+		".godot/imported/x.ctex": "x",
+	} {
+		writeTreeFile(t, installed, rel, content)
+	}
+	if got, err := HashInstalledTree(installed, pkg); err != nil || got != pinned {
+		t.Fatalf("expected generated editor files to be ignored, got %q want %q (err=%v)", got, pinned, err)
+	}
+	if got, err := HashTree(installed); err != nil || got == pinned {
+		t.Fatalf("expected HashTree to include every file, got %q (err=%v)", got, err)
+	}
+	if diff, err := DiffTrees(pkg, installed); err != nil || !diff.Empty() {
+		t.Fatalf("expected no differences, got %+v (err=%v)", diff, err)
+	}
+
+	writeTreeFile(t, installed, "shipped.gd.uid", "uid://edited\n")
+	if got, err := HashInstalledTree(installed, pkg); err != nil || got == pinned {
+		t.Fatalf("expected an edited shipped editor file to change the hash, got %q (err=%v)", got, err)
+	}
+	if got, err := HashInstalledTree(installed, ""); err != nil || got == pinned {
+		t.Fatalf("expected the hash without shipped editor files to differ, got %q (err=%v)", got, err)
+	}
+	diff, err := DiffTrees(pkg, installed)
+	if err != nil {
+		t.Fatalf("DiffTrees: %v", err)
+	}
+	if len(diff.Modified) != 1 || diff.Modified[0] != "shipped.gd.uid" || len(diff.Missing) != 0 || len(diff.Extra) != 0 {
+		t.Fatalf("expected only shipped.gd.uid to be modified, got %+v", diff)
+	}
+}
+
+func TestCopyEditorFiles(t *testing.T) {
+	dir := t.TempDir()
+	installed := filepath.Join(dir, "installed")
+	for rel, content := range map[string]string{
+		"a.gd":            "extends Node\n",
+		"a.gd.uid":        "uid://local\n",
+		"b.gd.uid":        "uid://gone\n",
+		"c.gd.uid":        "uid://old\n",
+		"icon.png":        "png",
+		"icon.png.import": "[remap]\n",
+	} {
+		writeTreeFile(t, installed, rel, content)
+	}
+	fetched := filepath.Join(dir, "fetched")
+	for rel, content := range map[string]string{"a.gd": "extends Node2D\n", "c.gd": "extends Node\n", "c.gd.uid": "uid://shipped\n", "icon.png": "png"} {
+		writeTreeFile(t, fetched, rel, content)
+	}
+
+	if err := CopyEditorFiles(installed, fetched); err != nil {
+		t.Fatalf("CopyEditorFiles: %v", err)
+	}
+	for rel, want := range map[string]string{"a.gd.uid": "uid://local\n", "c.gd.uid": "uid://shipped\n", "icon.png.import": "[remap]\n"} {
+		if b, err := os.ReadFile(filepath.Join(fetched, rel)); err != nil || string(b) != want {
+			t.Fatalf("expected %s to be %q, got %q (err=%v)", rel, want, b, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(fetched, "b.gd.uid")); !os.IsNotExist(err) {
+		t.Fatalf("expected .uid of a removed script not to be carried over, got err=%v", err)
+	}
+}
+
+func writeTreeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
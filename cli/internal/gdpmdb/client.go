@@ -8,14 +8,20 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/aviorstudio/gdpm/cli/internal/semver"
 )
 
+const defaultPageSize = 1000
+
 type Client struct {
 	baseURL    string
 	anonKey    string
 	httpClient *http.Client
+	pageSize   int
 }
 
 func NewDefaultClient() *Client {
@@ -31,6 +37,7 @@ func NewClient(baseURL, anonKey string) *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		pageSize: defaultPageSize,
 	}
 }
 
@@ -41,6 +48,8 @@ type ResolvedPlugin struct {
 	GitHubOwner  string
 	GitHubRepo   string
 	GitHubSubdir string
+	Host         string
+	GitRemote    string
 
 	Version string
 	SHA     string
@@ -52,77 +61,169 @@ func (c *Client) ResolvePlugin(ctx context.Context, username, plugin, requestedV
 	if usernameNormal == "" || pluginName == "" {
 		return ResolvedPlugin{}, fmt.Errorf("invalid plugin spec")
 	}
+	requestedVersion = strings.TrimSpace(requestedVersion)
+	if requestedVersion != "" {
+		if _, err := semver.ParseConstraint(requestedVersion); err != nil {
+			return ResolvedPlugin{}, err
+		}
+	}
 
-	userRow, ok, err := c.getUsernameByNormal(ctx, usernameNormal)
+	pluginRow, err := c.lookupPlugin(ctx, usernameNormal, pluginName)
 	if err != nil {
 		return ResolvedPlugin{}, err
 	}
-	if !ok {
-		return ResolvedPlugin{}, fmt.Errorf("owner not found: @%s", usernameNormal)
+	versionRows, err := c.listPluginVersions(ctx, pluginRow.ID)
+	if err != nil {
+		return ResolvedPlugin{}, err
 	}
-	if userRow.UserID != nil && userRow.OrgID != nil {
-		return ResolvedPlugin{}, fmt.Errorf("username is assigned to multiple owners: @%s", usernameNormal)
+	return resolveVersion("@"+usernameNormal+"/"+pluginName, pluginRow.Repo, pluginRow.Path, versionRows, requestedVersion)
+}
+
+type PluginVersion struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+	SHA        string
+	CreatedAt  string
+}
+
+func (v PluginVersion) String() string {
+	if v.Prerelease != "" {
+		return fmt.Sprintf("%d.%d.%d-%s", v.Major, v.Minor, v.Patch, v.Prerelease)
 	}
-	if userRow.UserID == nil && userRow.OrgID == nil {
-		return ResolvedPlugin{}, fmt.Errorf("owner not found: @%s", usernameNormal)
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+func (c *Client) ListPluginVersions(ctx context.Context, username, plugin string) ([]PluginVersion, error) {
+	usernameNormal := strings.ToLower(strings.TrimSpace(username))
+	pluginName := strings.TrimSpace(plugin)
+	if usernameNormal == "" || pluginName == "" {
+		return nil, fmt.Errorf("invalid plugin spec")
 	}
 
-	pluginRow, ok, err := c.getPluginByOwnerAndName(ctx, userRow.UserID, userRow.OrgID, pluginName)
+	pluginRow, err := c.lookupPlugin(ctx, usernameNormal, pluginName)
 	if err != nil {
-		return ResolvedPlugin{}, err
+		return nil, err
 	}
-	if !ok {
-		return ResolvedPlugin{}, fmt.Errorf("plugin not found: @%s/%s", usernameNormal, pluginName)
+	rows, err := c.listPluginVersions(ctx, pluginRow.ID)
+	if err != nil {
+		return nil, err
 	}
-	if strings.TrimSpace(pluginRow.Repo) == "" {
-		return ResolvedPlugin{}, fmt.Errorf("plugin has no repository set: @%s/%s", usernameNormal, pluginName)
+
+	versions := make([]PluginVersion, 0, len(rows))
+	for _, row := range rows {
+		if strings.TrimSpace(row.SHA) == "" {
+			continue
+		}
+		versions = append(versions, pluginVersionFromRow(row))
 	}
+	return versions, nil
+}
 
-	versionRows, err := c.listPluginVersions(ctx, pluginRow.ID)
+func (c *Client) PluginInfo(ctx context.Context, username, plugin string) (PluginInfo, error) {
+	usernameNormal := strings.ToLower(strings.TrimSpace(username))
+	pluginName := strings.TrimSpace(plugin)
+	if usernameNormal == "" || pluginName == "" {
+		return PluginInfo{}, fmt.Errorf("invalid plugin spec")
+	}
+
+	row, err := c.lookupPlugin(ctx, usernameNormal, pluginName)
 	if err != nil {
-		return ResolvedPlugin{}, err
+		return PluginInfo{}, err
+	}
+	versionRows, err := c.listPluginVersions(ctx, row.ID)
+	if err != nil {
+		return PluginInfo{}, err
+	}
+
+	info := PluginInfo{
+		Name:      "@" + usernameNormal + "/" + pluginName,
+		Owner:     usernameNormal,
+		OwnerType: "user",
+		Plugin:    pluginName,
+		Repo:      row.Repo,
+	}
+	if row.OrgID != nil && strings.TrimSpace(*row.OrgID) != "" {
+		info.OwnerType = "org"
 	}
-	selected, ok := selectVersion(versionRows, requestedVersion)
+	if row.Path != nil {
+		info.Path = strings.Trim(strings.TrimSpace(*row.Path), "/")
+	}
+	for _, v := range versionRows {
+		info.Versions = append(info.Versions, pluginVersionFromRow(v))
+	}
+	return info, nil
+}
+
+func SelectPluginVersion(versions []PluginVersion, requested string, includePrerelease bool) (PluginVersion, bool) {
+	rows := make([]versionRow, 0, len(versions))
+	for _, v := range versions {
+		row := versionRow{
+			Major: v.Major,
+			Minor: v.Minor,
+			Patch: v.Patch,
+			SHA:   v.SHA,
+		}
+		if v.Prerelease != "" {
+			pre := v.Prerelease
+			row.Prerelease = &pre
+		}
+		rows = append(rows, row)
+	}
+	selected, ok := selectVersion(rows, requested, includePrerelease)
 	if !ok {
-		return ResolvedPlugin{}, fmt.Errorf("version not found: %s", requestedVersion)
+		return PluginVersion{}, false
+	}
+	for _, v := range versions {
+		if v.Major == selected.Major && v.Minor == selected.Minor && v.Patch == selected.Patch && v.Prerelease == rowPrerelease(selected) && v.SHA == selected.SHA {
+			return v, true
+		}
+	}
+	return PluginVersion{}, false
+}
+
+func pluginVersionFromRow(row versionRow) PluginVersion {
+	v := PluginVersion{
+		Major:      row.Major,
+		Minor:      row.Minor,
+		Patch:      row.Patch,
+		Prerelease: rowPrerelease(row),
+		SHA:        strings.TrimSpace(row.SHA),
 	}
-	sha := strings.TrimSpace(selected.SHA)
-	if sha == "" {
-		return ResolvedPlugin{}, fmt.Errorf(
-			"selected version has no sha: %d.%d.%d",
-			selected.Major,
-			selected.Minor,
-			selected.Patch,
-		)
+	if row.CreatedAt != nil {
+		v.CreatedAt = strings.TrimSpace(*row.CreatedAt)
 	}
+	return v
+}
 
-	ghOwner, ghRepo, repoSubdir, err := ParseGitHubRepoURL(pluginRow.Repo)
+func (c *Client) lookupPlugin(ctx context.Context, usernameNormal, pluginName string) (pluginRow, error) {
+	userRow, ok, err := c.getUsernameByNormal(ctx, usernameNormal)
 	if err != nil {
-		return ResolvedPlugin{}, err
+		return pluginRow{}, err
 	}
-
-	ghSubdir := repoSubdir
-	if pluginRow.Path != nil {
-		repoPath := strings.TrimSpace(*pluginRow.Path)
-		if repoPath != "" {
-			repoPath = strings.ReplaceAll(repoPath, "\\", "/")
-			repoPath = strings.Trim(repoPath, "/")
-			ghSubdir = repoPath
-		}
+	if !ok {
+		return pluginRow{}, fmt.Errorf("owner not found: @%s", usernameNormal)
+	}
+	if userRow.UserID != nil && userRow.OrgID != nil {
+		return pluginRow{}, fmt.Errorf("username is assigned to multiple owners: @%s", usernameNormal)
+	}
+	if userRow.UserID == nil && userRow.OrgID == nil {
+		return pluginRow{}, fmt.Errorf("owner not found: @%s", usernameNormal)
 	}
 
-	return ResolvedPlugin{
-		Name:         "@" + usernameNormal + "/" + pluginName,
-		Repo:         pluginRow.Repo,
-		GitHubOwner:  ghOwner,
-		GitHubRepo:   ghRepo,
-		GitHubSubdir: ghSubdir,
-		Version:      fmt.Sprintf("%d.%d.%d", selected.Major, selected.Minor, selected.Patch),
-		SHA:          sha,
-	}, nil
+	row, ok, err := c.getPluginByOwnerAndName(ctx, userRow.UserID, userRow.OrgID, pluginName)
+	if err != nil {
+		return pluginRow{}, err
+	}
+	if !ok {
+		return pluginRow{}, fmt.Errorf("plugin not found: @%s/%s", usernameNormal, pluginName)
+	}
+	return row, nil
 }
 
 type usernameRow struct {
+	UsernameNormal  *string `json:"username_normal"`
 	UsernameDisplay *string `json:"username_display"`
 	UserID          *string `json:"user_id"`
 	OrgID           *string `json:"org_id"`
@@ -139,12 +240,13 @@ type pluginRow struct {
 }
 
 type versionRow struct {
-	PluginID  *string `json:"plugin_id"`
-	Major     int     `json:"major"`
-	Minor     int     `json:"minor"`
-	Patch     int     `json:"patch"`
-	SHA       string  `json:"sha"`
-	CreatedAt *string `json:"created_at"`
+	PluginID   *string `json:"plugin_id"`
+	Major      int     `json:"major"`
+	Minor      int     `json:"minor"`
+	Patch      int     `json:"patch"`
+	Prerelease *string `json:"prerelease"`
+	SHA        string  `json:"sha"`
+	CreatedAt  *string `json:"created_at"`
 }
 
 func (c *Client) getUsernameByNormal(ctx context.Context, usernameNormal string) (usernameRow, bool, error) {
@@ -168,9 +270,6 @@ func (c *Client) getUsernameByNormal(ctx context.Context, usernameNormal string)
 
 func (c *Client) getPluginByOwnerAndName(ctx context.Context, userID, orgID *string, pluginName string) (pluginRow, bool, error) {
 	q := url.Values{}
-	selectWithPath := "id,name,repo,path,created_at,user_id,org_id"
-	selectLegacy := "id,name,repo,created_at,user_id,org_id"
-	q.Set("select", selectWithPath)
 	q.Set("name", "eq."+pluginName)
 	q.Set("limit", "2")
 
@@ -182,6 +281,24 @@ func (c *Client) getPluginByOwnerAndName(ctx context.Context, userID, orgID *str
 		return pluginRow{}, false, fmt.Errorf("owner has no id")
 	}
 
+	rows, err := c.getPluginRows(ctx, q)
+	if err != nil {
+		return pluginRow{}, false, err
+	}
+	if len(rows) == 0 {
+		return pluginRow{}, false, nil
+	}
+	if len(rows) > 1 {
+		return pluginRow{}, false, fmt.Errorf("plugin is not unique: %s", pluginName)
+	}
+	return rows[0], true, nil
+}
+
+func (c *Client) getPluginRows(ctx context.Context, q url.Values) ([]pluginRow, error) {
+	selectWithPath := "id,name,repo,path,created_at,user_id,org_id"
+	selectLegacy := "id,name,repo,created_at,user_id,org_id"
+	q.Set("select", selectWithPath)
+
 	var rows []pluginRow
 	if err := c.get(ctx, "plugins", q, &rows); err != nil {
 		errMsg := strings.ToLower(err.Error())
@@ -190,19 +307,13 @@ func (c *Client) getPluginByOwnerAndName(ctx context.Context, userID, orgID *str
 			q.Set("select", selectLegacy)
 			rows = nil
 			if err2 := c.get(ctx, "plugins", q, &rows); err2 != nil {
-				return pluginRow{}, false, err2
+				return nil, err2
 			}
 		} else {
-			return pluginRow{}, false, err
+			return nil, err
 		}
 	}
-	if len(rows) == 0 {
-		return pluginRow{}, false, nil
-	}
-	if len(rows) > 1 {
-		return pluginRow{}, false, fmt.Errorf("plugin is not unique: %s", pluginName)
-	}
-	return rows[0], true, nil
+	return rows, nil
 }
 
 func (c *Client) listPluginVersions(ctx context.Context, pluginID string) ([]versionRow, error) {
@@ -212,13 +323,11 @@ func (c *Client) listPluginVersions(ctx context.Context, pluginID string) ([]ver
 	}
 
 	q := url.Values{}
-	q.Set("select", "plugin_id,major,minor,patch,sha,created_at")
 	q.Set("plugin_id", "eq."+pluginID)
 	q.Set("order", "major.desc,minor.desc,patch.desc,created_at.desc")
-	q.Set("limit", "100")
 
-	var rows []versionRow
-	if err := c.get(ctx, "plugin_versions", q, &rows); err != nil {
+	rows, err := c.getVersionRows(ctx, q)
+	if err != nil {
 		return nil, err
 	}
 	if rows == nil {
@@ -227,10 +336,94 @@ func (c *Client) listPluginVersions(ctx context.Context, pluginID string) ([]ver
 	return rows, nil
 }
 
+func (c *Client) getVersionRows(ctx context.Context, q url.Values) ([]versionRow, error) {
+	selectWithPrerelease := "plugin_id,major,minor,patch,prerelease,sha,created_at"
+	selectLegacy := "plugin_id,major,minor,patch,sha,created_at"
+	q.Set("select", selectWithPrerelease)
+
+	rows, err := getAllPages[versionRow](ctx, c, "plugin_versions", q)
+	if err != nil {
+		errMsg := strings.ToLower(err.Error())
+		if !strings.Contains(errMsg, "prerelease") ||
+			!(strings.Contains(errMsg, "does not exist") || strings.Contains(errMsg, "could not find") || strings.Contains(errMsg, "schema cache")) {
+			return nil, err
+		}
+		q.Set("select", selectLegacy)
+		if rows, err = getAllPages[versionRow](ctx, c, "plugin_versions", q); err != nil {
+			return nil, err
+		}
+	}
+
+	// The database orders by the numeric columns only; pre-releases of the
+	// same major.minor.patch still need semver ordering.
+	sortVersionRows(rows)
+	return rows, nil
+}
+
 func (c *Client) get(ctx context.Context, table string, query url.Values, dst any) error {
+	_, err := c.request(ctx, table, query, nil, dst)
+	return err
+}
+
+func getAllPages[T any](ctx context.Context, c *Client, table string, query url.Values) ([]T, error) {
+	pageSize := c.pageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	var all []T
+	offset := 0
+	for {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = append([]string(nil), v...)
+		}
+		q.Set("limit", strconv.Itoa(pageSize))
+		if offset > 0 {
+			q.Set("offset", strconv.Itoa(offset))
+		}
+
+		var rows []T
+		header, err := c.request(ctx, table, q, http.Header{"Prefer": {"count=exact"}}, &rows)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, rows...)
+		offset += len(rows)
+		if len(rows) == 0 {
+			return all, nil
+		}
+
+		// The server may cap a page below the requested limit, so prefer the
+		// total from Content-Range over the size of the page.
+		if total, ok := contentRangeTotal(header.Get("Content-Range")); ok {
+			if offset >= total {
+				return all, nil
+			}
+			continue
+		}
+		if len(rows) < pageSize {
+			return all, nil
+		}
+	}
+}
+
+func contentRangeTotal(value string) (int, bool) {
+	_, total, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok || total == "*" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(total)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+func (c *Client) request(ctx context.Context, table string, query url.Values, header http.Header, dst any) (http.Header, error) {
 	u, err := url.Parse(c.baseURL)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	u.Path = path.Join(u.Path, "rest/v1", table)
 	if !strings.HasPrefix(u.Path, "/") {
@@ -240,7 +433,10 @@ func (c *Client) get(ctx context.Context, table string, query url.Values, dst an
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
 	}
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("apikey", c.anonKey)
@@ -248,14 +444,14 @@ func (c *Client) get(ctx context.Context, table string, query url.Values, dst an
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
 		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 32<<10))
-		return fmt.Errorf("gdpm db failed (%d): %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+		return nil, fmt.Errorf("gdpm db failed (%d): %s", resp.StatusCode, strings.TrimSpace(string(msg)))
 	}
 
-	return json.NewDecoder(resp.Body).Decode(dst)
+	return resp.Header, json.NewDecoder(resp.Body).Decode(dst)
 }
@@ -0,0 +1,227 @@
+package gdpmdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakePostgREST struct {
+	t          *testing.T
+	maxRows    int
+	countExact bool
+	legacy     bool
+	versions   []versionRow
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func newFakePostgREST(t *testing.T, versionCount int) *fakePostgREST {
+	pluginID := "p1"
+	f := &fakePostgREST{t: t, countExact: true}
+	// Versions are served newest first, matching the order the client asks
+	// for; the oldest release is 0.1.0.
+	for i := versionCount - 1; i >= 0; i-- {
+		f.versions = append(f.versions, versionRow{
+			PluginID: &pluginID,
+			Major:    0,
+			Minor:    i/10 + 1,
+			Patch:    i % 10,
+			SHA:      fmt.Sprintf("%040x", i+1),
+		})
+	}
+	return f
+}
+
+func (f *fakePostgREST) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.URL.Path+"?"+r.URL.RawQuery)
+	f.mu.Unlock()
+
+	q := r.URL.Query()
+	switch r.URL.Path {
+	case "/rest/v1/usernames":
+		userID := "u1"
+		writeFakeRows(w, []usernameRow{{UserID: &userID}})
+	case "/rest/v1/plugins":
+		name := "tween"
+		path := ""
+		writeFakeRows(w, []pluginRow{{ID: "p1", Name: &name, Repo: "https://github.com/alice/tween", Path: &path}})
+	case "/rest/v1/plugin_versions":
+		if f.legacy && strings.Contains(q.Get("select"), "prerelease") {
+			http.Error(w, `{"message":"column plugin_versions.prerelease does not exist"}`, http.StatusBadRequest)
+			return
+		}
+		limit, err := strconv.Atoi(q.Get("limit"))
+		if err != nil {
+			f.t.Errorf("plugin_versions request without a limit: %s", r.URL.RawQuery)
+			limit = len(f.versions)
+		}
+		if f.maxRows > 0 && limit > f.maxRows {
+			limit = f.maxRows
+		}
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		start := min(offset, len(f.versions))
+		end := min(start+limit, len(f.versions))
+		page := f.versions[start:end]
+
+		total := "*"
+		if f.countExact && r.Header.Get("Prefer") == "count=exact" {
+			total = strconv.Itoa(len(f.versions))
+		}
+		if len(page) == 0 {
+			w.Header().Set("Content-Range", "*/"+total)
+		} else {
+			w.Header().Set("Content-Range", fmt.Sprintf("%d-%d/%s", start, end-1, total))
+		}
+		writeFakeRows(w, page)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakePostgREST) versionRequests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, r := range f.requests {
+		if strings.HasPrefix(r, "/rest/v1/plugin_versions?") {
+			n++
+		}
+	}
+	return n
+}
+
+func writeFakeRows(w http.ResponseWriter, rows any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rows)
+}
+
+func newTestClient(t *testing.T, f *fakePostgREST, pageSize int) *Client {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	c := NewClient(srv.URL, "test-key")
+	c.pageSize = pageSize
+	return c
+}
+
+func TestClient_ListPluginVersionsPagesThroughFullHistory(t *testing.T) {
+	f := newFakePostgREST(t, 250)
+	c := newTestClient(t, f, 100)
+
+	versions, err := c.ListPluginVersions(context.Background(), "alice", "tween")
+	if err != nil {
+		t.Fatalf("ListPluginVersions: %v", err)
+	}
+	if len(versions) != 250 {
+		t.Fatalf("expected 250 versions, got %d", len(versions))
+	}
+	if got := versions[len(versions)-1].String(); got != "0.1.0" {
+		t.Fatalf("expected oldest version 0.1.0 last, got %s", got)
+	}
+	if n := f.versionRequests(); n != 3 {
+		t.Fatalf("expected 3 page requests, got %d", n)
+	}
+}
+
+func TestClient_ResolvePluginFindsVersionsPastFirstPage(t *testing.T) {
+	f := newFakePostgREST(t, 150)
+	c := newTestClient(t, f, defaultPageSize)
+	// Simulate a server-side max-rows cap smaller than the requested page.
+	f.maxRows = 100
+
+	resolved, err := c.ResolvePlugin(context.Background(), "alice", "tween", "0.1.0")
+	if err != nil {
+		t.Fatalf("ResolvePlugin: %v", err)
+	}
+	if resolved.Version != "0.1.0" || resolved.SHA != fmt.Sprintf("%040x", 1) {
+		t.Fatalf("unexpected resolution: %+v", resolved)
+	}
+}
+
+func TestClient_PagesWithoutExactCount(t *testing.T) {
+	f := newFakePostgREST(t, 120)
+	f.countExact = false
+	c := newTestClient(t, f, 50)
+
+	versions, err := c.ListPluginVersions(context.Background(), "alice", "tween")
+	if err != nil {
+		t.Fatalf("ListPluginVersions: %v", err)
+	}
+	if len(versions) != 120 {
+		t.Fatalf("expected 120 versions, got %d", len(versions))
+	}
+}
+
+func TestContentRangeTotal(t *testing.T) {
+	tests := []struct {
+		in    string
+		total int
+		ok    bool
+	}{
+		{"0-99/250", 250, true},
+		{"*/0", 0, true},
+		{"0-99/*", 0, false},
+		{"", 0, false},
+		{"0-99/abc", 0, false},
+	}
+	for _, tt := range tests {
+		total, ok := contentRangeTotal(tt.in)
+		if total != tt.total || ok != tt.ok {
+			t.Fatalf("contentRangeTotal(%q) = %d, %v; want %d, %v", tt.in, total, ok, tt.total, tt.ok)
+		}
+	}
+}
+
+func TestClient_ListPluginVersionsOrdersPrereleases(t *testing.T) {
+	f := newFakePostgREST(t, 0)
+	pluginID := "p1"
+	beta2, beta10 := "beta.2", "beta.10"
+	// The database only orders by the numeric columns.
+	f.versions = []versionRow{
+		{PluginID: &pluginID, Major: 2, SHA: "aaa", Prerelease: &beta2},
+		{PluginID: &pluginID, Major: 2, SHA: "bbb"},
+		{PluginID: &pluginID, Major: 2, SHA: "ccc", Prerelease: &beta10},
+		{PluginID: &pluginID, Major: 1, SHA: "ddd"},
+	}
+	c := newTestClient(t, f, defaultPageSize)
+
+	versions, err := c.ListPluginVersions(context.Background(), "alice", "tween")
+	if err != nil {
+		t.Fatalf("ListPluginVersions: %v", err)
+	}
+	var got []string
+	for _, v := range versions {
+		got = append(got, v.String())
+	}
+	if strings.Join(got, " ") != "2.0.0 2.0.0-beta.10 2.0.0-beta.2 1.0.0" {
+		t.Fatalf("unexpected order: %v", got)
+	}
+
+	resolved, err := c.ResolvePlugin(context.Background(), "alice", "tween", "2.0.0-beta.10")
+	if err != nil || resolved.SHA != "ccc" {
+		t.Fatalf("expected exact pre-release resolution, got %+v (err=%v)", resolved, err)
+	}
+}
+
+func TestClient_FallsBackWithoutPrereleaseColumn(t *testing.T) {
+	f := newFakePostgREST(t, 5)
+	f.legacy = true
+	c := newTestClient(t, f, defaultPageSize)
+
+	versions, err := c.ListPluginVersions(context.Background(), "alice", "tween")
+	if err != nil {
+		t.Fatalf("ListPluginVersions: %v", err)
+	}
+	if len(versions) != 5 {
+		t.Fatalf("expected 5 versions, got %d", len(versions))
+	}
+}
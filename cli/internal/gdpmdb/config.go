@@ -0,0 +1,163 @@
+package gdpmdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
+)
+
+const ConfigEnv = "GDPM_CONFIG"
+
+type Config struct {
+	Registries  []RegistryConfig   `json:"registries"`
+	GitHubHosts []GitHubHostConfig `json:"github_hosts,omitempty"`
+	ForgeHosts  []ForgeHostConfig  `json:"forge_hosts,omitempty"`
+}
+
+type RegistryConfig struct {
+	Name    string   `json:"name"`
+	URL     string   `json:"url"`
+	Key     string   `json:"key,omitempty"`
+	KeyEnv  string   `json:"key_env,omitempty"`
+	Scopes  []string `json:"scopes,omitempty"`
+	Default bool     `json:"default,omitempty"`
+}
+
+func DefaultConfigPath() (string, error) {
+	if v := strings.TrimSpace(os.Getenv(ConfigEnv)); v != "" {
+		p, err := fsutil.ExpandHome(v)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Abs(p)
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "gdpm", "config.json"), nil
+}
+
+func LoadConfig(path string) (Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func loadDefaultConfig() (Config, error) {
+	path, err := DefaultConfigPath()
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		if os.IsNotExist(err) && strings.TrimSpace(os.Getenv(ConfigEnv)) == "" {
+			return Config{}, nil
+		}
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c Config) validate() error {
+	names := map[string]bool{}
+	scopes := map[string]string{}
+	hasDefault := false
+	for i, r := range c.Registries {
+		name := strings.TrimSpace(r.Name)
+		if name == "" {
+			return fmt.Errorf("registries[%d] has no name", i)
+		}
+		if names[name] {
+			return fmt.Errorf("duplicate registry name %q", name)
+		}
+		names[name] = true
+
+		if strings.TrimSpace(r.URL) == "" {
+			return fmt.Errorf("registry %q has no url", name)
+		}
+		if r.Key != "" && r.KeyEnv != "" {
+			return fmt.Errorf("registry %q sets both key and key_env", name)
+		}
+		if r.Default {
+			if hasDefault {
+				return fmt.Errorf("more than one default registry")
+			}
+			hasDefault = true
+		}
+		if len(r.Scopes) == 0 && !r.Default {
+			return fmt.Errorf("registry %q has no scopes and is not the default", name)
+		}
+		for _, scope := range r.Scopes {
+			owner, ok := normalizeScope(scope)
+			if !ok {
+				return fmt.Errorf("registry %q has invalid scope %q (expected @owner)", name, scope)
+			}
+			if other, ok := scopes[owner]; ok {
+				return fmt.Errorf("scope @%s is served by both %q and %q", owner, other, name)
+			}
+			scopes[owner] = name
+		}
+	}
+
+	hosts := map[string]bool{}
+	for _, h := range c.GitHubHosts {
+		if err := h.validate(); err != nil {
+			return err
+		}
+		host := normalizeGitHubHost(h.Host)
+		if hosts[host] {
+			return fmt.Errorf("duplicate github host %q", host)
+		}
+		hosts[host] = true
+	}
+	for _, h := range c.ForgeHosts {
+		if err := h.validate(); err != nil {
+			return err
+		}
+		host := normalizeGitHubHost(h.Host)
+		if hosts[host] {
+			return fmt.Errorf("duplicate host %q in github_hosts or forge_hosts", host)
+		}
+		hosts[host] = true
+	}
+	return nil
+}
+
+func (r RegistryConfig) key() (string, error) {
+	if env := strings.TrimSpace(r.KeyEnv); env != "" {
+		v := strings.TrimSpace(os.Getenv(env))
+		if v == "" {
+			return "", fmt.Errorf("registry %q needs $%s to be set", r.Name, env)
+		}
+		return v, nil
+	}
+	return strings.TrimSpace(r.Key), nil
+}
+
+func normalizeScope(scope string) (string, bool) {
+	owner := strings.ToLower(strings.TrimSpace(scope))
+	owner = strings.TrimPrefix(owner, "@")
+	owner = strings.TrimSuffix(owner, "/*")
+	if owner == "" || strings.ContainsAny(owner, "/@ ") {
+		return "", false
+	}
+	return owner, true
+}
@@ -0,0 +1,256 @@
+package gdpmdb
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	ForgeGitHub = "github"
+	ForgeGitLab = "gitlab"
+	ForgeGitea  = "gitea"
+)
+
+func ForgeForHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if IsGitHubHost(host) {
+		return ForgeGitHub
+	}
+	if forge := builtinForge(host); forge != "" {
+		return forge
+	}
+	if cfg, ok, _ := ForgeHost(host); ok {
+		return cfg.Forge
+	}
+	switch {
+	case strings.HasPrefix(host, "gitlab."):
+		return ForgeGitLab
+	case strings.HasPrefix(host, "gitea.") || strings.HasPrefix(host, "forgejo."):
+		return ForgeGitea
+	}
+	return ""
+}
+
+func TreeURLWithPath(host, owner, repo, ref, repoPath string) string {
+	switch ForgeForHost(host) {
+	case ForgeGitLab:
+		return GitLabTreeURLWithPath(host, owner, repo, ref, repoPath)
+	case ForgeGitea:
+		return GiteaTreeURLWithPath(host, owner, repo, ref, repoPath)
+	}
+	if IsDefaultGitHubHost(host) {
+		return GitHubTreeURLWithPath(owner, repo, ref, repoPath)
+	}
+	return githubTreeURLWithPath(host, owner, repo, ref, repoPath)
+}
+
+func ParseTreeURLWithPath(treeURL string) (string, string, string, string, string, error) {
+	host, err := treeURLHost(treeURL)
+	if err != nil {
+		return "", "", "", "", "", err
+	}
+	switch ForgeForHost(host) {
+	case ForgeGitHub:
+		owner, repo, ref, repoPath, err := ParseGitHubTreeURLWithPath(treeURL)
+		return GitHubLockHost(host), owner, repo, ref, repoPath, err
+	case ForgeGitLab:
+		return ParseGitLabTreeURLWithPath(treeURL)
+	case ForgeGitea:
+		return ParseGiteaTreeURLWithPath(treeURL)
+	}
+	return "", "", "", "", "", unsupportedHostError(host)
+}
+
+func ParseSourceURL(value string) (string, string, string, string, string, error) {
+	host, err := treeURLHost(NormalizeRepoURL(value))
+	if err != nil {
+		return "", "", "", "", "", err
+	}
+	switch ForgeForHost(host) {
+	case ForgeGitHub:
+		owner, repo, ref, repoPath, err := ParseGitHubSourceURL(value)
+		return GitHubLockHost(host), owner, repo, ref, repoPath, err
+	case ForgeGitLab:
+		return parseGitLabSourceURL(NormalizeRepoURL(value))
+	case ForgeGitea:
+		return parseGiteaSourceURL(NormalizeRepoURL(value))
+	}
+	return "", "", "", "", "", unsupportedHostError(host)
+}
+
+func GitHubLockHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if IsDefaultGitHubHost(host) {
+		return "github.com"
+	}
+	return host
+}
+
+func unsupportedHostError(host string) error {
+	if _, _, err := GitHubHost(host); err != nil {
+		return err
+	}
+	return fmt.Errorf("unsupported repo host %s (use github.com, a host from %s, a GitLab or Gitea host, or a git+ url)", host, GitHubHostsEnv)
+}
+
+func GitLabTreeURLWithPath(host, namespace, repo, ref, repoPath string) string {
+	base := "https://" + host + "/" + namespace + "/" + repo + "/-/tree/" + url.PathEscape(ref)
+	return base + escapedRepoPath(repoPath)
+}
+
+func ParseGitLabTreeURLWithPath(treeURL string) (string, string, string, string, string, error) {
+	host, parts, err := splitTreeURL(treeURL)
+	if err != nil {
+		return "", "", "", "", "", err
+	}
+	sep := indexOf(parts, "-")
+	if sep < 2 || len(parts) < sep+3 || parts[sep+1] != "tree" {
+		return "", "", "", "", "", fmt.Errorf("invalid gitlab tree url (expected %s/group/project/-/tree/ref): %s", host, treeURL)
+	}
+	namespace, repo := strings.Join(parts[:sep-1], "/"), parts[sep-1]
+	ref, repoPath, err := treeRefAndPath(parts[sep+2], parts[sep+3:], treeURL)
+	if err != nil {
+		return "", "", "", "", "", err
+	}
+	return host, namespace, repo, ref, repoPath, nil
+}
+
+func GiteaTreeURLWithPath(host, owner, repo, ref, repoPath string) string {
+	base := "https://" + host + "/" + owner + "/" + repo + "/src/commit/" + url.PathEscape(ref)
+	return base + escapedRepoPath(repoPath)
+}
+
+func ParseGiteaTreeURLWithPath(treeURL string) (string, string, string, string, string, error) {
+	host, parts, err := splitTreeURL(treeURL)
+	if err != nil {
+		return "", "", "", "", "", err
+	}
+	if len(parts) < 5 || parts[0] == "" || parts[1] == "" || parts[2] != "src" || !giteaRefKind(parts[3]) {
+		return "", "", "", "", "", fmt.Errorf("invalid gitea tree url (expected %s/owner/repo/src/commit/sha): %s", host, treeURL)
+	}
+	ref, repoPath, err := treeRefAndPath(parts[4], parts[5:], treeURL)
+	if err != nil {
+		return "", "", "", "", "", err
+	}
+	return host, parts[0], parts[1], ref, repoPath, nil
+}
+
+func parseGitLabSourceURL(repoURL string) (string, string, string, string, string, error) {
+	host, parts, err := splitTreeURL(repoURL)
+	if err != nil {
+		return "", "", "", "", "", err
+	}
+	if sep := indexOf(parts, "-"); sep >= 0 {
+		if len(parts) == sep+1 || parts[sep+1] != "tree" {
+			return "", "", "", "", "", fmt.Errorf("invalid gitlab repo path (expected %s/group/project[/-/tree/ref/path]): %s", host, repoURL)
+		}
+		return ParseGitLabTreeURLWithPath(repoURL)
+	}
+	if len(parts) < 2 {
+		return "", "", "", "", "", fmt.Errorf("invalid gitlab repo path (expected %s/group/project): %s", host, repoURL)
+	}
+	return host, strings.Join(parts[:len(parts)-1], "/"), strings.TrimSuffix(parts[len(parts)-1], ".git"), "", "", nil
+}
+
+func parseGiteaSourceURL(repoURL string) (string, string, string, string, string, error) {
+	host, parts, err := splitTreeURL(repoURL)
+	if err != nil {
+		return "", "", "", "", "", err
+	}
+	if len(parts) > 2 {
+		return ParseGiteaTreeURLWithPath(repoURL)
+	}
+	if len(parts) < 2 {
+		return "", "", "", "", "", fmt.Errorf("invalid gitea repo path (expected %s/owner/repo): %s", host, repoURL)
+	}
+	return host, parts[0], strings.TrimSuffix(parts[1], ".git"), "", "", nil
+}
+
+func giteaRefKind(kind string) bool {
+	switch kind {
+	case "commit", "branch", "tag":
+		return true
+	}
+	return false
+}
+
+func treeURLHost(treeURL string) (string, error) {
+	treeURL = strings.TrimSpace(treeURL)
+	if treeURL == "" {
+		return "", fmt.Errorf("empty tree url")
+	}
+	u, err := url.Parse(treeURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid tree url: %w", err)
+	}
+	return strings.ToLower(strings.TrimSpace(u.Host)), nil
+}
+
+func splitTreeURL(treeURL string) (string, []string, error) {
+	treeURL = strings.TrimSpace(treeURL)
+	if treeURL == "" {
+		return "", nil, fmt.Errorf("empty tree url")
+	}
+	u, err := url.Parse(treeURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid tree url: %w", err)
+	}
+	parts := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	for _, part := range parts {
+		if part == "" {
+			return "", nil, fmt.Errorf("invalid repo path: %s", treeURL)
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(u.Host)), parts, nil
+}
+
+func treeRefAndPath(rawRef string, rawPath []string, treeURL string) (string, string, error) {
+	ref, err := url.PathUnescape(rawRef)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid tree ref: %w", err)
+	}
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", "", fmt.Errorf("empty tree ref: %s", treeURL)
+	}
+
+	var repoPathParts []string
+	for _, part := range rawPath {
+		unescaped, err := url.PathUnescape(part)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid tree path: %w", err)
+		}
+		switch unescaped = strings.TrimSpace(unescaped); unescaped {
+		case "":
+			continue
+		case ".", "..":
+			return "", "", fmt.Errorf("invalid tree path: %s", treeURL)
+		}
+		if strings.Contains(unescaped, "\\") {
+			return "", "", fmt.Errorf("invalid tree path (unexpected \\\\): %s", treeURL)
+		}
+		repoPathParts = append(repoPathParts, unescaped)
+	}
+	return ref, strings.Join(repoPathParts, "/"), nil
+}
+
+func escapedRepoPath(repoPath string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(strings.Trim(strings.TrimSpace(repoPath), "/"), "/") {
+		if part == "" {
+			continue
+		}
+		b.WriteString("/" + url.PathEscape(part))
+	}
+	return b.String()
+}
+
+func indexOf(parts []string, value string) int {
+	for i, part := range parts {
+		if part == value {
+			return i
+		}
+	}
+	return -1
+}
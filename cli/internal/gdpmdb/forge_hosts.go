@@ -0,0 +1,98 @@
+package gdpmdb
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	GitLabTokenEnv = "GITLAB_TOKEN"
+	GiteaTokenEnv  = "GITEA_TOKEN"
+)
+
+type ForgeHostConfig struct {
+	Host     string `json:"host"`
+	Forge    string `json:"forge"`
+	Token    string `json:"token,omitempty"`
+	TokenEnv string `json:"token_env,omitempty"`
+}
+
+func (h ForgeHostConfig) AuthToken() string {
+	if env := strings.TrimSpace(h.TokenEnv); env != "" {
+		return strings.TrimSpace(os.Getenv(env))
+	}
+	return strings.TrimSpace(h.Token)
+}
+
+func ForgeHost(host string) (ForgeHostConfig, bool, error) {
+	host = normalizeGitHubHost(host)
+	if host == "" {
+		return ForgeHostConfig{}, false, nil
+	}
+	cfg, err := loadDefaultConfig()
+	if err != nil {
+		return ForgeHostConfig{}, false, err
+	}
+	for _, h := range cfg.ForgeHosts {
+		if normalizeGitHubHost(h.Host) == host {
+			h.Host = host
+			h.Forge = strings.ToLower(strings.TrimSpace(h.Forge))
+			return h, true, nil
+		}
+	}
+	return ForgeHostConfig{}, false, nil
+}
+
+func ForgeToken(host string) (string, error) {
+	host = normalizeGitHubHost(host)
+	cfg, ok, err := ForgeHost(host)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return cfg.AuthToken(), nil
+	}
+	// GITLAB_TOKEN and GITEA_TOKEN belong to the public forges. A host that
+	// is only guessed from its name (gitlab.*, gitea.*) may be anyone's
+	// server, so it gets a token only through forge_hosts.
+	switch host {
+	case "gitlab.com":
+		return strings.TrimSpace(os.Getenv(GitLabTokenEnv)), nil
+	case "codeberg.org", "gitea.com":
+		return strings.TrimSpace(os.Getenv(GiteaTokenEnv)), nil
+	}
+	return "", nil
+}
+
+func builtinForge(host string) string {
+	switch host {
+	case "gitlab.com":
+		return ForgeGitLab
+	case "codeberg.org", "gitea.com":
+		return ForgeGitea
+	}
+	return ""
+}
+
+func (h ForgeHostConfig) validate() error {
+	host := normalizeGitHubHost(h.Host)
+	if host == "" {
+		return fmt.Errorf("forge host has no host name")
+	}
+	if strings.ContainsAny(host, "/@ ") {
+		return fmt.Errorf("invalid forge host %q (expected a host name like git.studio.example)", h.Host)
+	}
+	if isBuiltinGitHubHost(host) || builtinForge(host) != "" {
+		return fmt.Errorf("forge host %q is built in and cannot be configured", host)
+	}
+	switch strings.ToLower(strings.TrimSpace(h.Forge)) {
+	case ForgeGitLab, ForgeGitea:
+	default:
+		return fmt.Errorf("forge host %q has invalid forge %q (expected %s or %s)", host, h.Forge, ForgeGitLab, ForgeGitea)
+	}
+	if h.Token != "" && h.TokenEnv != "" {
+		return fmt.Errorf("forge host %q sets both token and token_env", host)
+	}
+	return nil
+}
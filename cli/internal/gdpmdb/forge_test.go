@@ -0,0 +1,152 @@
+package gdpmdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestForgeForHost(t *testing.T) {
+	cases := map[string]string{
+		"":                 ForgeGitHub,
+		"github.com":       ForgeGitHub,
+		"gitlab.com":       ForgeGitLab,
+		"gitlab.corp.test": ForgeGitLab,
+		"codeberg.org":     ForgeGitea,
+		"gitea.com":        ForgeGitea,
+		"example.com":      "",
+	}
+	for host, want := range cases {
+		if got := ForgeForHost(host); got != want {
+			t.Fatalf("ForgeForHost(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestForgeHost_ConfiguredHostsAndTokens(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{
+  "registries": [],
+  "forge_hosts": [
+    {"host": "git.studio.example", "forge": "gitea", "token_env": "STUDIO_GITEA_TOKEN"},
+    {"host": "gitlab.partner.example", "forge": "gitlab"}
+  ]
+}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv(ConfigEnv, configPath)
+	t.Setenv(GitLabTokenEnv, "gitlab-token")
+	t.Setenv(GiteaTokenEnv, "gitea-token")
+	t.Setenv("STUDIO_GITEA_TOKEN", "studio-token")
+
+	if got := ForgeForHost("git.studio.example"); got != ForgeGitea {
+		t.Fatalf("ForgeForHost(git.studio.example) = %q, want %q", got, ForgeGitea)
+	}
+
+	for host, want := range map[string]string{
+		"gitlab.com":             "gitlab-token",
+		"codeberg.org":           "gitea-token",
+		"gitea.com":              "gitea-token",
+		"git.studio.example":     "studio-token",
+		"gitlab.partner.example": "",
+		"gitlab.evil.example":    "",
+		"forgejo.evil.example":   "",
+	} {
+		got, err := ForgeToken(host)
+		if err != nil {
+			t.Fatalf("ForgeToken(%q): %v", host, err)
+		}
+		if got != want {
+			t.Fatalf("ForgeToken(%q) = %q, want %q", host, got, want)
+		}
+	}
+
+	for _, body := range []string{
+		`{"registries": [], "forge_hosts": [{"host": "gitlab.com", "forge": "gitlab"}]}`,
+		`{"registries": [], "forge_hosts": [{"host": "git.a.example", "forge": "bitbucket"}]}`,
+		`{"registries": [], "forge_hosts": [{"host": "git.a.example", "forge": "gitea", "token": "x", "token_env": "Y"}]}`,
+		`{"registries": [], "github_hosts": [{"host": "git.a.example"}], "forge_hosts": [{"host": "git.a.example", "forge": "gitea"}]}`,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Fatalf("expected config to be rejected: %s", body)
+		}
+	}
+}
+
+func TestGitLabTreeURLWithPath_RoundTrip(t *testing.T) {
+	u := GitLabTreeURLWithPath("gitlab.com", "studio/godot", "addons", "feature/foo", "addons/ui")
+	if u != "https://gitlab.com/studio/godot/addons/-/tree/feature%2Ffoo/addons/ui" {
+		t.Fatalf("unexpected url: %s", u)
+	}
+	host, namespace, repo, ref, repoPath, err := ParseTreeURLWithPath(u)
+	if err != nil {
+		t.Fatalf("ParseTreeURLWithPath: %v", err)
+	}
+	if host != "gitlab.com" || namespace != "studio/godot" || repo != "addons" || ref != "feature/foo" || repoPath != "addons/ui" {
+		t.Fatalf("unexpected result: %s %s/%s@%s (%s)", host, namespace, repo, ref, repoPath)
+	}
+}
+
+func TestGiteaTreeURLWithPath_RoundTrip(t *testing.T) {
+	u := GiteaTreeURLWithPath("codeberg.org", "studio", "addons", "abc123", "addons/ui")
+	if u != "https://codeberg.org/studio/addons/src/commit/abc123/addons/ui" {
+		t.Fatalf("unexpected url: %s", u)
+	}
+	host, owner, repo, ref, repoPath, err := ParseTreeURLWithPath(u)
+	if err != nil {
+		t.Fatalf("ParseTreeURLWithPath: %v", err)
+	}
+	if host != "codeberg.org" || owner != "studio" || repo != "addons" || ref != "abc123" || repoPath != "addons/ui" {
+		t.Fatalf("unexpected result: %s %s/%s@%s (%s)", host, owner, repo, ref, repoPath)
+	}
+
+	if _, _, _, ref, _, err := ParseGiteaTreeURLWithPath("https://codeberg.org/studio/addons/src/branch/main"); err != nil || ref != "main" {
+		t.Fatalf("expected branch ref main, got %q (%v)", ref, err)
+	}
+}
+
+func TestParseTreeURLWithPath_RecordsGitHubHost(t *testing.T) {
+	host, owner, repo, ref, _, err := ParseTreeURLWithPath("https://github.com/aviorstudio/revik/tree/abc123")
+	if err != nil {
+		t.Fatalf("ParseTreeURLWithPath: %v", err)
+	}
+	if host != "github.com" || owner != "aviorstudio" || repo != "revik" || ref != "abc123" {
+		t.Fatalf("unexpected result: %q %s/%s@%s", host, owner, repo, ref)
+	}
+}
+
+func TestParseTreeURLWithPath_RejectsUnknownHost(t *testing.T) {
+	if _, _, _, _, _, err := ParseTreeURLWithPath("https://example.com/owner/repo/tree/abc123"); err == nil {
+		t.Fatalf("expected unknown host to be rejected")
+	}
+}
+
+func TestParseSourceURL(t *testing.T) {
+	cases := []struct {
+		in                               string
+		host, owner, repo, ref, repoPath string
+	}{
+		{"github.com/owner/repo", "github.com", "owner", "repo", "", ""},
+		{"gitlab.com/group/sub/project", "gitlab.com", "group/sub", "project", "", ""},
+		{"https://gitlab.com/group/project/-/tree/v1.0.0/addons/ui", "gitlab.com", "group", "project", "v1.0.0", "addons/ui"},
+		{"codeberg.org/owner/repo.git", "codeberg.org", "owner", "repo", "", ""},
+		{"https://codeberg.org/owner/repo/src/tag/v2.0.0/addons/ui", "codeberg.org", "owner", "repo", "v2.0.0", "addons/ui"},
+	}
+	for _, tc := range cases {
+		host, owner, repo, ref, repoPath, err := ParseSourceURL(tc.in)
+		if err != nil {
+			t.Fatalf("ParseSourceURL(%q): %v", tc.in, err)
+		}
+		if host != tc.host || owner != tc.owner || repo != tc.repo || ref != tc.ref || repoPath != tc.repoPath {
+			t.Fatalf("ParseSourceURL(%q) = %q %s/%s@%s (%s)", tc.in, host, owner, repo, ref, repoPath)
+		}
+	}
+
+	if _, _, _, _, _, err := ParseSourceURL("https://gitlab.com/group/project/-/merge_requests/1"); err == nil {
+		t.Fatalf("expected non-tree gitlab url to be rejected")
+	}
+}
@@ -0,0 +1,84 @@
+package gdpmdb
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const gitSourcePrefix = "git+"
+
+func IsGitSourceURL(value string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(value)), gitSourcePrefix)
+}
+
+func GitSourceURL(remote, ref, repoPath string) string {
+	s := gitSourcePrefix + strings.TrimSpace(remote)
+	ref = strings.TrimSpace(ref)
+	repoPath = strings.Trim(strings.TrimSpace(repoPath), "/")
+	if ref == "" && repoPath == "" {
+		return s
+	}
+	s += "#" + ref
+	if repoPath != "" {
+		s += ":" + repoPath
+	}
+	return s
+}
+
+func ParseGitSourceURL(value string) (string, string, string, error) {
+	value = strings.TrimSpace(value)
+	if !IsGitSourceURL(value) {
+		return "", "", "", fmt.Errorf("git source must start with %s (got %s)", gitSourcePrefix, value)
+	}
+	rest := value[len(gitSourcePrefix):]
+
+	remote, fragment, _ := strings.Cut(rest, "#")
+	remote = strings.TrimSpace(remote)
+	if remote == "" {
+		return "", "", "", fmt.Errorf("empty git remote: %s", value)
+	}
+	if strings.HasPrefix(remote, "-") {
+		return "", "", "", fmt.Errorf("invalid git remote (must not start with -): %s", value)
+	}
+	ref, repoPath, _ := strings.Cut(fragment, ":")
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "-") {
+		return "", "", "", fmt.Errorf("invalid git ref (must not start with -): %s", value)
+	}
+	repoPath = strings.Trim(strings.TrimSpace(repoPath), "/")
+	if repoPath != "" {
+		if strings.Contains(repoPath, "\\") {
+			return "", "", "", fmt.Errorf("invalid git source path (unexpected \\\\): %s", value)
+		}
+		for _, part := range strings.Split(repoPath, "/") {
+			switch part {
+			case "", ".", "..":
+				return "", "", "", fmt.Errorf("invalid git source path: %s", value)
+			}
+		}
+	}
+	return remote, ref, repoPath, nil
+}
+
+func GitRemoteOwnerRepo(remote string) (string, string) {
+	remote = strings.TrimSpace(remote)
+	p := remote
+	host := ""
+	if u, err := url.Parse(remote); err == nil && u.Scheme != "" {
+		p = u.Path
+		host = u.Hostname()
+	} else if m := gitSSHRe.FindStringSubmatch(remote); m != nil {
+		host = m[1]
+		p = m[2]
+	}
+
+	p = strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+	parts := strings.Split(strings.Trim(p, "/"), "/")
+	repo := parts[len(parts)-1]
+	owner := host
+	if len(parts) >= 2 {
+		owner = parts[len(parts)-2]
+	}
+	return owner, repo
+}
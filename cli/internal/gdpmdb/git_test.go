@@ -0,0 +1,59 @@
+package gdpmdb
+
+import "testing"
+
+func TestParseGitSourceURL(t *testing.T) {
+	tests := []struct {
+		in       string
+		remote   string
+		ref      string
+		repoPath string
+	}{
+		{"git+https://git.example.com/studio/addons.git", "https://git.example.com/studio/addons.git", "", ""},
+		{"git+https://git.example.com/studio/addons.git#v1.2.0", "https://git.example.com/studio/addons.git", "v1.2.0", ""},
+		{"git+ssh://git@bitbucket.org/studio/addons.git#main:addons/ui", "ssh://git@bitbucket.org/studio/addons.git", "main", "addons/ui"},
+		{"git+git@git.example.com:studio/addons.git#:addons/ui/", "git@git.example.com:studio/addons.git", "", "addons/ui"},
+		{"git+file:///srv/git/addons.git#0123456789abcdef0123456789abcdef01234567", "file:///srv/git/addons.git", "0123456789abcdef0123456789abcdef01234567", ""},
+	}
+
+	for _, tt := range tests {
+		remote, ref, repoPath, err := ParseGitSourceURL(tt.in)
+		if err != nil {
+			t.Fatalf("ParseGitSourceURL(%q) error: %v", tt.in, err)
+		}
+		if remote != tt.remote || ref != tt.ref || repoPath != tt.repoPath {
+			t.Fatalf("ParseGitSourceURL(%q) = %q, %q, %q; want %q, %q, %q", tt.in, remote, ref, repoPath, tt.remote, tt.ref, tt.repoPath)
+		}
+		if tt.ref != "" || tt.repoPath != "" {
+			if got := GitSourceURL(remote, ref, repoPath); got != GitSourceURL(tt.remote, tt.ref, tt.repoPath) {
+				t.Fatalf("GitSourceURL round trip for %q = %q", tt.in, got)
+			}
+		}
+	}
+
+	for _, in := range []string{"https://git.example.com/a.git", "git+", "git+https://x/a.git#main:../up", "git+--upload-pack=touch /tmp/x", "git+https://x/a.git#--output=/tmp/x"} {
+		if _, _, _, err := ParseGitSourceURL(in); err == nil {
+			t.Fatalf("ParseGitSourceURL(%q): expected error", in)
+		}
+	}
+}
+
+func TestGitRemoteOwnerRepo(t *testing.T) {
+	tests := []struct {
+		in    string
+		owner string
+		repo  string
+	}{
+		{"https://git.example.com/studio/addons.git", "studio", "addons"},
+		{"git@git.example.com:studio/addons.git", "studio", "addons"},
+		{"ssh://git@bitbucket.org/team/sub/addons", "sub", "addons"},
+		{"https://git.example.com/addons.git", "git.example.com", "addons"},
+		{"file:///srv/git/addons.git", "git", "addons"},
+	}
+	for _, tt := range tests {
+		owner, repo := GitRemoteOwnerRepo(tt.in)
+		if owner != tt.owner || repo != tt.repo {
+			t.Fatalf("GitRemoteOwnerRepo(%q) = %s/%s, want %s/%s", tt.in, owner, repo, tt.owner, tt.repo)
+		}
+	}
+}
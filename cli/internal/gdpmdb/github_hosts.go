@@ -0,0 +1,173 @@
+package gdpmdb
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	GitHubHostsEnv           = "GDPM_GITHUB_HOSTS"
+	GitHubEnterpriseTokenEnv = "GITHUB_ENTERPRISE_TOKEN"
+)
+
+type GitHubHostConfig struct {
+	Host     string `json:"host"`
+	APIURL   string `json:"api_url,omitempty"`
+	Token    string `json:"token,omitempty"`
+	TokenEnv string `json:"token_env,omitempty"`
+}
+
+func (h GitHubHostConfig) APIBaseURL() string {
+	if u := strings.TrimRight(strings.TrimSpace(h.APIURL), "/"); u != "" {
+		return u
+	}
+	return "https://" + h.Host + "/api/v3"
+}
+
+func (h GitHubHostConfig) AuthToken() string {
+	// GITHUB_TOKEN belongs to github.com and is never sent to another host.
+	if env := strings.TrimSpace(h.TokenEnv); env != "" {
+		return strings.TrimSpace(os.Getenv(env))
+	}
+	if token := strings.TrimSpace(h.Token); token != "" {
+		return token
+	}
+	return strings.TrimSpace(os.Getenv(GitHubEnterpriseTokenEnv))
+}
+
+// CheckTokenTransport refuses to send a token to an API that is not served
+// over HTTPS.
+func (h GitHubHostConfig) CheckTokenTransport() error {
+	if h.AuthToken() == "" {
+		return nil
+	}
+	if u, err := url.Parse(h.APIBaseURL()); err != nil || u.Scheme != "https" {
+		return fmt.Errorf("github host %q has a token but its api_url %q is not https", h.Host, h.APIBaseURL())
+	}
+	return nil
+}
+
+func isBuiltinGitHubHost(host string) bool {
+	return host == "github.com" || host == "www.github.com"
+}
+
+// IsDefaultGitHubHost reports whether host is github.com. Sources that name
+// no host at all are on github.com too.
+func IsDefaultGitHubHost(host string) bool {
+	host = strings.ToLower(strings.TrimSpace(host))
+	return host == "" || isBuiltinGitHubHost(host)
+}
+
+func IsGitHubHost(host string) bool {
+	if IsDefaultGitHubHost(host) {
+		return true
+	}
+	_, ok, _ := GitHubHost(host)
+	return ok
+}
+
+func GitHubHost(host string) (GitHubHostConfig, bool, error) {
+	host = normalizeGitHubHost(host)
+	if host == "" {
+		return GitHubHostConfig{}, false, nil
+	}
+	hosts, err := loadGitHubHosts()
+	if err != nil {
+		return GitHubHostConfig{}, false, err
+	}
+	for _, h := range hosts {
+		if h.Host == host {
+			return h, true, nil
+		}
+	}
+	return GitHubHostConfig{}, false, nil
+}
+
+func ParseGitHubHostsEnv(value string) ([]GitHubHostConfig, error) {
+	var hosts []GitHubHostConfig
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		host, apiURL, _ := strings.Cut(entry, "=")
+		h := GitHubHostConfig{
+			Host:   normalizeGitHubHost(host),
+			APIURL: strings.TrimSpace(apiURL),
+		}
+		if err := h.validate(); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", GitHubHostsEnv, err)
+		}
+		hosts = append(hosts, h)
+	}
+	return hosts, nil
+}
+
+func loadGitHubHosts() ([]GitHubHostConfig, error) {
+	cfg, err := loadDefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+	fromEnv, err := ParseGitHubHostsEnv(os.Getenv(GitHubHostsEnv))
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := append([]GitHubHostConfig(nil), cfg.GitHubHosts...)
+	for i := range hosts {
+		hosts[i].Host = normalizeGitHubHost(hosts[i].Host)
+	}
+	for _, h := range fromEnv {
+		merged := false
+		for i := range hosts {
+			if hosts[i].Host != h.Host {
+				continue
+			}
+			// Tokens stay in the config file; the env var only adds hosts
+			// or overrides where their API lives.
+			if h.APIURL != "" {
+				hosts[i].APIURL = h.APIURL
+			}
+			merged = true
+		}
+		if !merged {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts, nil
+}
+
+func (h GitHubHostConfig) validate() error {
+	host := normalizeGitHubHost(h.Host)
+	if host == "" {
+		return fmt.Errorf("github host has no host name")
+	}
+	if strings.ContainsAny(host, "/@ ") {
+		return fmt.Errorf("invalid github host %q (expected a host name like git.corp.example)", h.Host)
+	}
+	if isBuiltinGitHubHost(host) {
+		return fmt.Errorf("github host %q is built in and cannot be configured", host)
+	}
+	if h.Token != "" && h.TokenEnv != "" {
+		return fmt.Errorf("github host %q sets both token and token_env", host)
+	}
+	if apiURL := strings.TrimSpace(h.APIURL); apiURL != "" {
+		u, err := url.Parse(apiURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("github host %q has invalid api_url %q", host, apiURL)
+		}
+		if u.Scheme != "https" && (h.Token != "" || h.TokenEnv != "") {
+			return fmt.Errorf("github host %q has a token but its api_url %q is not https", host, apiURL)
+		}
+	}
+	return nil
+}
+
+func normalizeGitHubHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	return strings.TrimRight(host, "/")
+}
@@ -0,0 +1,162 @@
+package gdpmdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseGitHubHostsEnv(t *testing.T) {
+	hosts, err := ParseGitHubHostsEnv(" git.corp.example , https://ghe.other.example=https://ghe.other.example/custom/api/ ")
+	if err != nil {
+		t.Fatalf("ParseGitHubHostsEnv: %v", err)
+	}
+	if len(hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %+v", hosts)
+	}
+	if hosts[0].Host != "git.corp.example" || hosts[0].APIBaseURL() != "https://git.corp.example/api/v3" {
+		t.Fatalf("unexpected first host: %+v (%s)", hosts[0], hosts[0].APIBaseURL())
+	}
+	if hosts[1].Host != "ghe.other.example" || hosts[1].APIBaseURL() != "https://ghe.other.example/custom/api" {
+		t.Fatalf("unexpected second host: %+v (%s)", hosts[1], hosts[1].APIBaseURL())
+	}
+
+	for _, bad := range []string{"github.com", "git.corp.example=not a url", "owner/repo"} {
+		if _, err := ParseGitHubHostsEnv(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestGitHubHost_MergesConfigAndEnv(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{
+  "registries": [],
+  "github_hosts": [
+    {"host": "git.corp.example", "token_env": "CORP_GHE_TOKEN"},
+    {"host": "ghe.inline.example", "token": "inline-token"}
+  ]
+}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv(ConfigEnv, configPath)
+	t.Setenv(GitHubHostsEnv, "git.corp.example=https://api.corp.example,ghe.env.example")
+	t.Setenv("CORP_GHE_TOKEN", "corp-token")
+	t.Setenv(GitHubEnterpriseTokenEnv, "shared-token")
+	t.Setenv("GITHUB_TOKEN", "github-token")
+
+	corp, ok, err := GitHubHost("git.corp.example")
+	if err != nil || !ok {
+		t.Fatalf("GitHubHost(git.corp.example) = %v, %v", ok, err)
+	}
+	if corp.APIBaseURL() != "https://api.corp.example" || corp.AuthToken() != "corp-token" {
+		t.Fatalf("unexpected corp host: %s %q", corp.APIBaseURL(), corp.AuthToken())
+	}
+
+	inline, ok, err := GitHubHost("ghe.inline.example")
+	if err != nil || !ok || inline.AuthToken() != "inline-token" {
+		t.Fatalf("unexpected inline host: %+v %v %v", inline, ok, err)
+	}
+
+	fromEnv, ok, err := GitHubHost("ghe.env.example")
+	if err != nil || !ok || fromEnv.AuthToken() != "shared-token" {
+		t.Fatalf("unexpected env host: %+v %v %v", fromEnv, ok, err)
+	}
+
+	if _, ok, _ := GitHubHost("git.unknown.example"); ok {
+		t.Fatalf("expected unknown host to be unconfigured")
+	}
+	if ForgeForHost("git.corp.example") != ForgeGitHub {
+		t.Fatalf("expected configured host to be a GitHub host")
+	}
+}
+
+func TestLoadConfig_RejectsInvalidGitHubHosts(t *testing.T) {
+	for _, body := range []string{
+		`{"registries": [], "github_hosts": [{"host": ""}]}`,
+		`{"registries": [], "github_hosts": [{"host": "a.example"}, {"host": "A.example"}]}`,
+		`{"registries": [], "github_hosts": [{"host": "a.example", "token": "x", "token_env": "Y"}]}`,
+		`{"registries": [], "github_hosts": [{"host": "a.example", "api_url": "http://a.example/api/v3", "token": "x"}]}`,
+		`{"registries": [], "github_hosts": [{"host": "a.example", "api_url": "http://a.example/api/v3", "token_env": "Y"}]}`,
+	} {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		if _, err := LoadConfig(path); err == nil {
+			t.Fatalf("expected config to be rejected: %s", body)
+		}
+	}
+}
+
+func TestGitHubHost_RefusesTokenOverPlainHTTP(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"registries": []}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv(ConfigEnv, configPath)
+	t.Setenv(GitHubHostsEnv, "git.corp.example=http://git.corp.example/api/v3")
+	t.Setenv(GitHubEnterpriseTokenEnv, "")
+
+	h, ok, err := GitHubHost("git.corp.example")
+	if err != nil || !ok {
+		t.Fatalf("GitHubHost = %v, %v", ok, err)
+	}
+	if err := h.CheckTokenTransport(); err != nil {
+		t.Fatalf("expected a plain-HTTP API without a token to be allowed: %v", err)
+	}
+
+	t.Setenv(GitHubEnterpriseTokenEnv, "shared-token")
+	if err := h.CheckTokenTransport(); err == nil {
+		t.Fatalf("expected a token over plain HTTP to be refused")
+	}
+}
+
+func TestParseTreeURLWithPath_GitHubEnterprise(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"registries": []}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv(ConfigEnv, configPath)
+	if _, _, _, _, _, err := ParseTreeURLWithPath("https://git.corp.example/owner/repo/tree/abc123"); err == nil {
+		t.Fatalf("expected an unconfigured host to be rejected")
+	}
+
+	t.Setenv(GitHubHostsEnv, "git.corp.example")
+	u := TreeURLWithPath("git.corp.example", "owner", "repo", "abc123", "addons/ui")
+	if u != "https://git.corp.example/owner/repo/tree/abc123/addons/ui" {
+		t.Fatalf("unexpected url: %s", u)
+	}
+	host, owner, repo, ref, repoPath, err := ParseTreeURLWithPath(u)
+	if err != nil {
+		t.Fatalf("ParseTreeURLWithPath: %v", err)
+	}
+	if host != "git.corp.example" || owner != "owner" || repo != "repo" || ref != "abc123" || repoPath != "addons/ui" {
+		t.Fatalf("unexpected result: %s %s/%s@%s (%s)", host, owner, repo, ref, repoPath)
+	}
+}
+
+func TestParseTreeURLWithPath_RejectsLookAlikeGitHubHosts(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"registries": []}`), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	t.Setenv(ConfigEnv, configPath)
+
+	for _, host := range []string{"notgithub.com", "evilgithub.com", "github.com.evil.example"} {
+		if IsGitHubHost(host) {
+			t.Fatalf("expected %s not to be treated as github.com", host)
+		}
+		if got := GitHubLockHost(host); got != host {
+			t.Fatalf("GitHubLockHost(%q) = %q, want the host itself", host, got)
+		}
+		if _, _, _, _, _, err := ParseTreeURLWithPath("https://" + host + "/owner/repo/tree/abc123"); err == nil {
+			t.Fatalf("expected https://%s tree url to be rejected", host)
+		}
+	}
+
+	host, _, _, _, _, err := ParseTreeURLWithPath("https://www.github.com/owner/repo/tree/abc123")
+	if err != nil || host != "github.com" {
+		t.Fatalf("expected www.github.com to be github.com, got host %q (%v)", host, err)
+	}
+}
@@ -0,0 +1,284 @@
+package gdpmdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aviorstudio/gdpm/cli/internal/semver"
+)
+
+type Index struct {
+	Plugins map[string]IndexPlugin `json:"plugins"`
+}
+
+type IndexPlugin struct {
+	Repo      string         `json:"repo"`
+	Path      string         `json:"path,omitempty"`
+	OwnerType string         `json:"owner_type,omitempty"`
+	Versions  []IndexVersion `json:"versions"`
+}
+
+type IndexVersion struct {
+	Version   string `json:"version"`
+	SHA       string `json:"sha"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+type IndexRegistry struct {
+	location   string
+	httpClient *http.Client
+
+	once  sync.Once
+	index Index
+	err   error
+}
+
+func NewIndexRegistry(location string) *IndexRegistry {
+	return &IndexRegistry{
+		location: strings.TrimSpace(location),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (r *IndexRegistry) ResolvePlugin(ctx context.Context, username, plugin, requestedVersion string) (ResolvedPlugin, error) {
+	requestedVersion = strings.TrimSpace(requestedVersion)
+	if requestedVersion != "" {
+		if _, err := semver.ParseConstraint(requestedVersion); err != nil {
+			return ResolvedPlugin{}, err
+		}
+	}
+
+	name, entry, err := r.lookup(ctx, username, plugin)
+	if err != nil {
+		return ResolvedPlugin{}, err
+	}
+	rows, err := entry.versionRows(name)
+	if err != nil {
+		return ResolvedPlugin{}, err
+	}
+	repoPath := entry.Path
+	return resolveVersion(name, entry.Repo, &repoPath, rows, requestedVersion)
+}
+
+func (r *IndexRegistry) ListPluginVersions(ctx context.Context, username, plugin string) ([]PluginVersion, error) {
+	name, entry, err := r.lookup(ctx, username, plugin)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := entry.versionRows(name)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]PluginVersion, 0, len(rows))
+	for _, row := range rows {
+		if strings.TrimSpace(row.SHA) == "" {
+			continue
+		}
+		versions = append(versions, pluginVersionFromRow(row))
+	}
+	return versions, nil
+}
+
+func (r *IndexRegistry) PluginInfo(ctx context.Context, username, plugin string) (PluginInfo, error) {
+	name, entry, err := r.lookup(ctx, username, plugin)
+	if err != nil {
+		return PluginInfo{}, err
+	}
+	rows, err := entry.versionRows(name)
+	if err != nil {
+		return PluginInfo{}, err
+	}
+
+	owner, pluginName, _ := splitPluginName(name)
+	info := PluginInfo{
+		Name:      name,
+		Owner:     owner,
+		OwnerType: strings.TrimSpace(entry.OwnerType),
+		Plugin:    pluginName,
+		Repo:      entry.Repo,
+		Path:      strings.Trim(entry.Path, "/"),
+	}
+	for _, row := range rows {
+		info.Versions = append(info.Versions, pluginVersionFromRow(row))
+	}
+	return info, nil
+}
+
+func (r *IndexRegistry) SearchPlugins(ctx context.Context, query string, opts SearchOptions) ([]PluginSummary, error) {
+	index, err := r.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	names := make([]string, 0, len(index.Plugins))
+	for name := range index.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var results []PluginSummary
+	for _, name := range names {
+		entry := index.Plugins[name]
+		if query != "" && !strings.Contains(strings.ToLower(name), query) && !strings.Contains(strings.ToLower(entry.Repo), query) {
+			continue
+		}
+		owner, plugin, ok := splitPluginName(name)
+		if !ok {
+			continue
+		}
+
+		summary := PluginSummary{
+			Name:   name,
+			Owner:  owner,
+			Plugin: plugin,
+			Repo:   entry.Repo,
+			Path:   strings.Trim(entry.Path, "/"),
+		}
+		if rows, err := entry.versionRows(name); err == nil {
+			if latest, ok := selectVersion(rows, "", false); ok {
+				summary.LatestVersion = rowVersionString(latest)
+			}
+		}
+		results = append(results, summary)
+	}
+	return pageSummaries(results, opts), nil
+}
+
+func (r *IndexRegistry) lookup(ctx context.Context, username, plugin string) (string, IndexPlugin, error) {
+	usernameNormal := strings.ToLower(strings.TrimSpace(username))
+	pluginName := strings.TrimSpace(plugin)
+	if usernameNormal == "" || pluginName == "" {
+		return "", IndexPlugin{}, fmt.Errorf("invalid plugin spec")
+	}
+
+	index, err := r.load(ctx)
+	if err != nil {
+		return "", IndexPlugin{}, err
+	}
+	name := "@" + usernameNormal + "/" + pluginName
+	entry, ok := index.Plugins[name]
+	if !ok {
+		return "", IndexPlugin{}, fmt.Errorf("plugin not found: %s", name)
+	}
+	return name, entry, nil
+}
+
+func (r *IndexRegistry) load(ctx context.Context) (Index, error) {
+	r.once.Do(func() {
+		b, err := r.read(ctx)
+		if err != nil {
+			r.err = fmt.Errorf("read registry index %s: %w", r.location, err)
+			return
+		}
+
+		var index Index
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&index); err != nil {
+			r.err = fmt.Errorf("invalid registry index %s: %w", r.location, err)
+			return
+		}
+
+		r.index = Index{Plugins: make(map[string]IndexPlugin, len(index.Plugins))}
+		for name, entry := range index.Plugins {
+			owner, plugin, ok := splitPluginName(name)
+			if !ok {
+				r.err = fmt.Errorf("invalid registry index %s: invalid plugin name %q", r.location, name)
+				return
+			}
+			switch entry.OwnerType {
+			case "", "user", "org":
+			default:
+				r.err = fmt.Errorf("invalid registry index %s: invalid owner_type %q for %s (expected user or org)", r.location, entry.OwnerType, name)
+				return
+			}
+			r.index.Plugins["@"+strings.ToLower(owner)+"/"+plugin] = entry
+		}
+	})
+	return r.index, r.err
+}
+
+func (r *IndexRegistry) read(ctx context.Context) ([]byte, error) {
+	lower := strings.ToLower(r.location)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return os.ReadFile(r.location)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.location, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (p IndexPlugin) versionRows(name string) ([]versionRow, error) {
+	rows := make([]versionRow, 0, len(p.Versions))
+	for _, v := range p.Versions {
+		parsed, ok := semver.Parse(v.Version)
+		if !ok {
+			return nil, fmt.Errorf("invalid version %q for %s in registry index", v.Version, name)
+		}
+		row := versionRow{
+			Major: parsed.Major,
+			Minor: parsed.Minor,
+			Patch: parsed.Patch,
+			SHA:   v.SHA,
+		}
+		if _, pre, ok := strings.Cut(parsed.String(), "-"); ok {
+			row.Prerelease = &pre
+		}
+		if v.CreatedAt != "" {
+			createdAt := v.CreatedAt
+			row.CreatedAt = &createdAt
+		}
+		rows = append(rows, row)
+	}
+
+	sortVersionRows(rows)
+	return rows, nil
+}
+
+func splitPluginName(name string) (string, string, bool) {
+	owner, plugin, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(name), "@"), "/")
+	if !ok || owner == "" || plugin == "" || strings.Contains(plugin, "/") {
+		return "", "", false
+	}
+	return owner, plugin, true
+}
+
+func pageSummaries(results []PluginSummary, opts SearchOptions) []PluginSummary {
+	if opts.Offset > 0 {
+		if opts.Offset >= len(results) {
+			return nil
+		}
+		results = results[opts.Offset:]
+	}
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+	return results
+}
@@ -0,0 +1,169 @@
+package gdpmdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testIndex = `{
+  "plugins": {
+    "@Studio/ui_kit": {
+      "repo": "https://github.com/studio/addons",
+      "path": "addons/ui_kit",
+      "versions": [
+        {"version": "1.0.0", "sha": "aaa"},
+        {"version": "1.2.0", "sha": "bbb", "created_at": "2024-01-02T00:00:00Z"},
+        {"version": "2.0.0", "sha": "ccc"}
+      ]
+    },
+    "@other/tween": {
+      "repo": "https://github.com/other/tween",
+      "versions": [{"version": "0.3.1", "sha": "ddd"}]
+    }
+  }
+}`
+
+func writeTestIndex(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), []byte(testIndex), 0o644); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+	return dir
+}
+
+func TestIndexRegistry_ResolveAndList(t *testing.T) {
+	dir := writeTestIndex(t)
+	r, err := OpenRegistry(dir, "")
+	if err != nil {
+		t.Fatalf("OpenRegistry: %v", err)
+	}
+	if _, ok := r.(*IndexRegistry); !ok {
+		t.Fatalf("expected directory to open an index registry, got %T", r)
+	}
+
+	ctx := context.Background()
+	resolved, err := r.ResolvePlugin(ctx, "studio", "ui_kit", "^1.0")
+	if err != nil {
+		t.Fatalf("ResolvePlugin: %v", err)
+	}
+	if resolved.Name != "@studio/ui_kit" || resolved.Version != "1.2.0" || resolved.SHA != "bbb" {
+		t.Fatalf("unexpected resolution: %+v", resolved)
+	}
+	if resolved.GitHubOwner != "studio" || resolved.GitHubRepo != "addons" || resolved.GitHubSubdir != "addons/ui_kit" {
+		t.Fatalf("unexpected source: %+v", resolved)
+	}
+
+	versions, err := r.ListPluginVersions(ctx, "studio", "ui_kit")
+	if err != nil {
+		t.Fatalf("ListPluginVersions: %v", err)
+	}
+	if len(versions) != 3 || versions[0].String() != "2.0.0" || versions[1].CreatedAt != "2024-01-02T00:00:00Z" {
+		t.Fatalf("unexpected versions: %+v", versions)
+	}
+
+	if _, err := r.ResolvePlugin(ctx, "studio", "missing", ""); err == nil {
+		t.Fatalf("expected missing plugin error")
+	}
+	if _, err := r.ResolvePlugin(ctx, "studio", "ui_kit", "3.0.0"); err == nil {
+		t.Fatalf("expected missing version error")
+	}
+}
+
+func TestIndexRegistry_Search(t *testing.T) {
+	r := NewIndexRegistry(filepath.Join(writeTestIndex(t), "index.json"))
+
+	results, err := r.SearchPlugins(context.Background(), "TWEEN", SearchOptions{})
+	if err != nil {
+		t.Fatalf("SearchPlugins: %v", err)
+	}
+	if len(results) != 1 || results[0].Name != "@other/tween" || results[0].LatestVersion != "0.3.1" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	results, err = r.SearchPlugins(context.Background(), "", SearchOptions{Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("SearchPlugins: %v", err)
+	}
+	if len(results) != 1 || results[0].Name != "@studio/ui_kit" {
+		t.Fatalf("unexpected page: %+v", results)
+	}
+}
+
+func TestOpenRegistry_SelectsBackend(t *testing.T) {
+	tests := []struct {
+		location string
+		index    bool
+	}{
+		{location: "https://example.supabase.co", index: false},
+		{location: "https://registry.example.com/index.json", index: true},
+		{location: "file:///srv/registry/index.json", index: true},
+		{location: "./registry.json", index: true},
+	}
+	for _, tt := range tests {
+		r, err := OpenRegistry(tt.location, "key")
+		if err != nil {
+			t.Fatalf("OpenRegistry(%q): %v", tt.location, err)
+		}
+		if _, ok := r.(*IndexRegistry); ok != tt.index {
+			t.Fatalf("OpenRegistry(%q) returned %T", tt.location, r)
+		}
+	}
+}
+
+func TestIndexRegistry_PluginInfo(t *testing.T) {
+	r := NewIndexRegistry(filepath.Join(writeTestIndex(t), "index.json"))
+
+	info, err := r.PluginInfo(context.Background(), "STUDIO", "ui_kit")
+	if err != nil {
+		t.Fatalf("PluginInfo: %v", err)
+	}
+	if info.Name != "@studio/ui_kit" || info.Owner != "studio" || info.Path != "addons/ui_kit" {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if len(info.Versions) != 3 || info.Versions[0].String() != "2.0.0" || info.Versions[2].SHA != "aaa" {
+		t.Fatalf("unexpected versions: %+v", info.Versions)
+	}
+}
+
+func TestIndexRegistry_Prerelease(t *testing.T) {
+	path := writeIndexFile(t, "index.json", `{"plugins":{
+  "@alice/tween":{"repo":"https://github.com/alice/tween","versions":[
+    {"version":"1.0.0","sha":"aaa"},
+    {"version":"2.0.0-beta.3","sha":"bbb"},
+    {"version":"2.0.0-beta.10","sha":"ccc"}
+  ]},
+  "@alice/nightly":{"repo":"https://github.com/alice/nightly","versions":[{"version":"0.1.0-rc.1","sha":"ddd"}]}
+}}`)
+	r, err := OpenRegistry(path, "")
+	if err != nil {
+		t.Fatalf("OpenRegistry: %v", err)
+	}
+
+	ctx := context.Background()
+	resolved, err := r.ResolvePlugin(ctx, "alice", "tween", "2.0.0-beta.3")
+	if err != nil {
+		t.Fatalf("resolve pre-release: %v", err)
+	}
+	if resolved.Version != "2.0.0-beta.3" || resolved.SHA != "bbb" {
+		t.Fatalf("unexpected resolution: %+v", resolved)
+	}
+	if resolved, err := r.ResolvePlugin(ctx, "alice", "tween", ""); err != nil || resolved.Version != "1.0.0" {
+		t.Fatalf("expected latest to skip pre-releases, got %+v (err=%v)", resolved, err)
+	}
+
+	versions, err := r.ListPluginVersions(ctx, "alice", "tween")
+	if err != nil {
+		t.Fatalf("ListPluginVersions: %v", err)
+	}
+	if len(versions) != 3 || versions[0].String() != "2.0.0-beta.10" || versions[1].Prerelease != "beta.3" {
+		t.Fatalf("unexpected versions: %+v", versions)
+	}
+
+	if _, err := r.ResolvePlugin(ctx, "alice", "nightly", ""); err == nil || !strings.Contains(err.Error(), "--pre") {
+		t.Fatalf("expected a hint about pre-releases, got: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package gdpmdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aviorstudio/gdpm/cli/internal/semver"
+)
+
+const (
+	RegistryEnv    = "GDPM_REGISTRY"
+	RegistryKeyEnv = "GDPM_REGISTRY_KEY"
+)
+
+type Registry interface {
+	ResolvePlugin(ctx context.Context, username, plugin, requestedVersion string) (ResolvedPlugin, error)
+	ListPluginVersions(ctx context.Context, username, plugin string) ([]PluginVersion, error)
+	PluginInfo(ctx context.Context, username, plugin string) (PluginInfo, error)
+	SearchPlugins(ctx context.Context, query string, opts SearchOptions) ([]PluginSummary, error)
+}
+
+type SearchOptions struct {
+	Limit  int
+	Offset int
+}
+
+type PluginSummary struct {
+	Name          string
+	Owner         string
+	Plugin        string
+	Repo          string
+	Path          string
+	LatestVersion string
+}
+
+type PluginInfo struct {
+	Name      string
+	Owner     string
+	OwnerType string
+	Plugin    string
+	Repo      string
+	Path      string
+	Versions  []PluginVersion
+}
+
+func NewDefaultRegistry() (Registry, error) {
+	cfg, err := loadDefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+	return NewRouter(cfg, os.Getenv(RegistryEnv), os.Getenv(RegistryKeyEnv))
+}
+
+func OpenRegistry(location, key string) (Registry, error) {
+	location = strings.TrimSpace(location)
+	key = strings.TrimSpace(key)
+	if location == "" {
+		return nil, fmt.Errorf("empty registry location")
+	}
+
+	lower := strings.ToLower(location)
+	switch {
+	case strings.HasPrefix(lower, "file://"):
+		u, err := url.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid registry location: %w", err)
+		}
+		return NewIndexRegistry(indexFilePath(u.Path)), nil
+	case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://"):
+		u, err := url.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid registry location: %w", err)
+		}
+		if strings.HasSuffix(strings.ToLower(u.Path), ".json") {
+			return NewIndexRegistry(location), nil
+		}
+		if key == "" && strings.TrimRight(location, "/") == DefaultSupabaseURL {
+			key = DefaultSupabaseAnonKey
+		}
+		return NewClient(location, key), nil
+	}
+
+	abs, err := filepath.Abs(location)
+	if err != nil {
+		return nil, err
+	}
+	return NewIndexRegistry(indexFilePath(abs)), nil
+}
+
+func indexFilePath(p string) string {
+	if info, err := os.Stat(p); err == nil && info.IsDir() {
+		return filepath.Join(p, "index.json")
+	}
+	return p
+}
+
+func resolveVersion(name, repo string, repoPath *string, rows []versionRow, requestedVersion string) (ResolvedPlugin, error) {
+	if strings.TrimSpace(repo) == "" {
+		return ResolvedPlugin{}, fmt.Errorf("plugin has no repository set: %s", name)
+	}
+
+	selected, ok := selectVersion(rows, requestedVersion, false)
+	if !ok {
+		if requestedVersion == "" && hasPrerelease(rows) {
+			return ResolvedPlugin{}, fmt.Errorf("no stable version of %s (only pre-releases are published; use --pre to include them)", name)
+		}
+		if requestedVersion != "" && !semver.IsExact(requestedVersion) {
+			return ResolvedPlugin{}, fmt.Errorf("no version matches %s", requestedVersion)
+		}
+		return ResolvedPlugin{}, fmt.Errorf("version not found: %s", requestedVersion)
+	}
+	sha := strings.TrimSpace(selected.SHA)
+	if sha == "" {
+		return ResolvedPlugin{}, fmt.Errorf("selected version has no sha: %s", rowVersionString(selected))
+	}
+
+	ghOwner, ghRepo, repoSubdir, err := ParseGitHubRepoURL(repo)
+	if err != nil {
+		return ResolvedPlugin{}, err
+	}
+
+	ghSubdir := repoSubdir
+	if repoPath != nil {
+		p := strings.TrimSpace(*repoPath)
+		if p != "" {
+			p = strings.ReplaceAll(p, "\\", "/")
+			p = strings.Trim(p, "/")
+			ghSubdir = p
+		}
+	}
+
+	return ResolvedPlugin{
+		Name:         name,
+		Repo:         repo,
+		GitHubOwner:  ghOwner,
+		GitHubRepo:   ghRepo,
+		GitHubSubdir: ghSubdir,
+		Host:         GitHubLockHost(repoHost(repo)),
+		Version:      rowVersionString(selected),
+		SHA:          sha,
+	}, nil
+}
@@ -47,6 +47,14 @@ func NormalizeRepoURL(value string) string {
 	return "https://" + sanitized
 }
 
+func repoHost(repo string) string {
+	u, err := url.Parse(NormalizeRepoURL(repo))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
 func ParseGitHubOwnerRepo(repo string) (string, string, error) {
 	owner, repoName, subdir, err := ParseGitHubRepoURL(repo)
 	if err != nil {
@@ -68,9 +76,9 @@ func ParseGitHubRepoURL(repo string) (string, string, string, error) {
 	if err != nil {
 		return "", "", "", fmt.Errorf("invalid repo url: %w", err)
 	}
-	host := strings.ToLower(strings.TrimSpace(u.Hostname()))
-	if !strings.HasSuffix(host, "github.com") {
-		return "", "", "", fmt.Errorf("only github.com repos are supported (got %s)", host)
+	host := strings.ToLower(strings.TrimSpace(u.Host))
+	if !IsGitHubHost(host) {
+		return "", "", "", fmt.Errorf("only github.com repos are supported (got %s; list GitHub Enterprise hosts in %s)", host, GitHubHostsEnv)
 	}
 
 	p := strings.Trim(u.Path, "/")
@@ -105,3 +113,21 @@ func ParseGitHubRepoURL(repo string) (string, string, string, error) {
 
 	return owner, repoName, subdir, nil
 }
+
+func ParseGitHubSourceURL(value string) (string, string, string, string, error) {
+	owner, repoName, subdir, err := ParseGitHubRepoURL(value)
+	if err != nil {
+		return "", "", "", "", err
+	}
+
+	u, err := url.Parse(NormalizeRepoURL(value))
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("invalid repo url: %w", err)
+	}
+	ref := ""
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) >= 4 && (parts[2] == "tree" || parts[2] == "blob") {
+		ref = strings.TrimSpace(parts[3])
+	}
+	return owner, repoName, ref, subdir, nil
+}
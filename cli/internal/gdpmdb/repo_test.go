@@ -49,3 +49,29 @@ func TestParseGitHubRepoURL(t *testing.T) {
 		}
 	}
 }
+
+func TestParseGitHubSourceURL(t *testing.T) {
+	tests := []struct {
+		in     string
+		owner  string
+		repo   string
+		ref    string
+		subdir string
+	}{
+		{"github.com/aviorstudio/revik", "aviorstudio", "revik", "", ""},
+		{"https://github.com/aviorstudio/revik/godot_core", "aviorstudio", "revik", "", "godot_core"},
+		{"https://github.com/aviorstudio/revik/tree/v1.2.0", "aviorstudio", "revik", "v1.2.0", ""},
+		{"github.com/aviorstudio/revik/tree/main/addons/revik", "aviorstudio", "revik", "main", "addons/revik"},
+		{"git@github.com:aviorstudio/revik.git", "aviorstudio", "revik", "", ""},
+	}
+
+	for _, tt := range tests {
+		owner, repo, ref, subdir, err := ParseGitHubSourceURL(tt.in)
+		if err != nil {
+			t.Fatalf("ParseGitHubSourceURL(%q) error: %v", tt.in, err)
+		}
+		if owner != tt.owner || repo != tt.repo || ref != tt.ref || subdir != tt.subdir {
+			t.Fatalf("ParseGitHubSourceURL(%q) = %s/%s@%s/%s, want %s/%s@%s/%s", tt.in, owner, repo, ref, subdir, tt.owner, tt.repo, tt.ref, tt.subdir)
+		}
+	}
+}
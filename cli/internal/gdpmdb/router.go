@@ -0,0 +1,182 @@
+package gdpmdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Router struct {
+	fallback Registry
+	routes   map[string]*route
+}
+
+type route struct {
+	config   RegistryConfig
+	registry Registry
+	err      error
+	opened   bool
+}
+
+func NewRouter(cfg Config, envLocation, envKey string) (Registry, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	r := &Router{routes: map[string]*route{}}
+
+	envLocation = strings.TrimSpace(envLocation)
+	var defaultConfig *RegistryConfig
+	for i := range cfg.Registries {
+		if cfg.Registries[i].Default {
+			defaultConfig = &cfg.Registries[i]
+		}
+	}
+	switch {
+	case envLocation != "":
+		fallback, err := OpenRegistry(envLocation, envKey)
+		if err != nil {
+			return nil, err
+		}
+		r.fallback = fallback
+	case defaultConfig != nil:
+		key, err := defaultConfig.key()
+		if err != nil {
+			return nil, err
+		}
+		fallback, err := OpenRegistry(defaultConfig.URL, key)
+		if err != nil {
+			return nil, fmt.Errorf("registry %q: %w", defaultConfig.Name, err)
+		}
+		r.fallback = fallback
+	default:
+		r.fallback = NewDefaultClient()
+	}
+
+	for _, rc := range cfg.Registries {
+		for _, scope := range rc.Scopes {
+			owner, _ := normalizeScope(scope)
+			if sameLocation(rc.URL, DefaultSupabaseURL) {
+				return nil, fmt.Errorf("scope @%s is assigned to registry %q, which points at the public registry", owner, rc.Name)
+			}
+			r.routes[owner] = &route{config: rc}
+		}
+	}
+	if len(r.routes) == 0 {
+		return r.fallback, nil
+	}
+	return r, nil
+}
+
+func (r *Router) registryFor(username string) (Registry, string, error) {
+	owner := strings.ToLower(strings.TrimSpace(username))
+	rt, ok := r.routes[owner]
+	if !ok {
+		return r.fallback, "", nil
+	}
+	if !rt.opened {
+		rt.opened = true
+		key, err := rt.config.key()
+		if err == nil {
+			rt.registry, err = OpenRegistry(rt.config.URL, key)
+		}
+		if err != nil {
+			rt.err = fmt.Errorf("@%s is served by registry %q and will not be looked up elsewhere: %w", owner, rt.config.Name, err)
+		}
+	}
+	return rt.registry, rt.config.Name, rt.err
+}
+
+func (r *Router) ResolvePlugin(ctx context.Context, username, plugin, requestedVersion string) (ResolvedPlugin, error) {
+	registry, name, err := r.registryFor(username)
+	if err != nil {
+		return ResolvedPlugin{}, err
+	}
+	resolved, err := registry.ResolvePlugin(ctx, username, plugin, requestedVersion)
+	if err != nil && name != "" {
+		return ResolvedPlugin{}, fmt.Errorf("registry %q: %w", name, err)
+	}
+	return resolved, err
+}
+
+func (r *Router) ListPluginVersions(ctx context.Context, username, plugin string) ([]PluginVersion, error) {
+	registry, name, err := r.registryFor(username)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := registry.ListPluginVersions(ctx, username, plugin)
+	if err != nil && name != "" {
+		return nil, fmt.Errorf("registry %q: %w", name, err)
+	}
+	return versions, err
+}
+
+func (r *Router) PluginInfo(ctx context.Context, username, plugin string) (PluginInfo, error) {
+	registry, name, err := r.registryFor(username)
+	if err != nil {
+		return PluginInfo{}, err
+	}
+	info, err := registry.PluginInfo(ctx, username, plugin)
+	if err != nil && name != "" {
+		return PluginInfo{}, fmt.Errorf("registry %q: %w", name, err)
+	}
+	return info, err
+}
+
+func (r *Router) SearchPlugins(ctx context.Context, query string, opts SearchOptions) ([]PluginSummary, error) {
+	perRegistry := SearchOptions{}
+	if opts.Limit > 0 {
+		perRegistry.Limit = opts.Limit + opts.Offset
+	}
+
+	results, err := r.fallback.SearchPlugins(ctx, query, perRegistry)
+	if err != nil {
+		return nil, err
+	}
+	merged := make([]PluginSummary, 0, len(results))
+	for _, res := range results {
+		if _, scoped := r.routes[strings.ToLower(res.Owner)]; !scoped {
+			merged = append(merged, res)
+		}
+	}
+
+	searched := map[string]bool{}
+	owners := make([]string, 0, len(r.routes))
+	for owner := range r.routes {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+	for _, owner := range owners {
+		registry, name, err := r.registryFor(owner)
+		if err != nil {
+			return nil, err
+		}
+		if searched[name] {
+			continue
+		}
+		searched[name] = true
+
+		results, err := registry.SearchPlugins(ctx, query, perRegistry)
+		if err != nil {
+			return nil, fmt.Errorf("registry %q: %w", name, err)
+		}
+		for _, res := range results {
+			if rt, ok := r.routes[strings.ToLower(res.Owner)]; ok && rt.config.Name == name {
+				merged = append(merged, res)
+			}
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Name < merged[j].Name
+	})
+	return pageSummaries(merged, opts), nil
+}
+
+func sameLocation(a, b string) bool {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.TrimRight(strings.TrimSpace(s), "/"))
+	}
+	return normalize(a) == normalize(b)
+}
@@ -0,0 +1,120 @@
+package gdpmdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeIndexFile(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return p
+}
+
+func TestRouter_RoutesScopedOwners(t *testing.T) {
+	public := writeIndexFile(t, "public.json", `{"plugins":{
+  "@ourstudio/secret":{"repo":"https://github.com/impostor/secret","versions":[{"version":"9.9.9","sha":"bad"}]},
+  "@other/tween":{"repo":"https://github.com/other/tween","versions":[{"version":"1.0.0","sha":"aaa"}]}
+}}`)
+	private := writeIndexFile(t, "private.json", `{"plugins":{
+  "@ourstudio/secret":{"repo":"https://github.com/ourstudio/secret","versions":[{"version":"1.0.0","sha":"bbb"}]}
+}}`)
+
+	cfg := Config{Registries: []RegistryConfig{
+		{Name: "studio", URL: private, Scopes: []string{"@OurStudio"}},
+	}}
+	r, err := NewRouter(cfg, public, "")
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+
+	ctx := context.Background()
+	resolved, err := r.ResolvePlugin(ctx, "ourstudio", "secret", "")
+	if err != nil {
+		t.Fatalf("resolve scoped: %v", err)
+	}
+	if resolved.SHA != "bbb" {
+		t.Fatalf("expected scoped owner to resolve from the private registry, got %+v", resolved)
+	}
+	if resolved, err := r.ResolvePlugin(ctx, "other", "tween", ""); err != nil || resolved.SHA != "aaa" {
+		t.Fatalf("expected fallback resolution, got %+v (err=%v)", resolved, err)
+	}
+	if _, err := r.ResolvePlugin(ctx, "ourstudio", "tween", ""); err == nil || !strings.Contains(err.Error(), `registry "studio"`) {
+		t.Fatalf("expected scoped miss not to fall back, got: %v", err)
+	}
+
+	results, err := r.SearchPlugins(ctx, "", SearchOptions{})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 2 || results[0].Name != "@other/tween" || results[1].Repo != "https://github.com/ourstudio/secret" {
+		t.Fatalf("unexpected search results: %+v", results)
+	}
+}
+
+func TestRouter_ScopedRegistryNeverFallsBack(t *testing.T) {
+	public := writeIndexFile(t, "public.json", `{"plugins":{}}`)
+	cfg := Config{Registries: []RegistryConfig{
+		{Name: "studio", URL: "https://registry.ourstudio.dev", KeyEnv: "GDPM_TEST_STUDIO_KEY", Scopes: []string{"@ourstudio"}},
+	}}
+	t.Setenv("GDPM_TEST_STUDIO_KEY", "")
+
+	r, err := NewRouter(cfg, public, "")
+	if err != nil {
+		t.Fatalf("NewRouter: %v", err)
+	}
+	_, err = r.ResolvePlugin(context.Background(), "ourstudio", "secret", "")
+	if err == nil || !strings.Contains(err.Error(), "will not be looked up elsewhere") || !strings.Contains(err.Error(), "$GDPM_TEST_STUDIO_KEY") {
+		t.Fatalf("expected missing credentials error, got: %v", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{
+			name: "duplicate scope",
+			cfg: Config{Registries: []RegistryConfig{
+				{Name: "a", URL: "https://a.example.com", Scopes: []string{"@x"}},
+				{Name: "b", URL: "https://b.example.com", Scopes: []string{"@X"}},
+			}},
+			want: "served by both",
+		},
+		{
+			name: "two defaults",
+			cfg: Config{Registries: []RegistryConfig{
+				{Name: "a", URL: "https://a.example.com", Default: true},
+				{Name: "b", URL: "https://b.example.com", Default: true},
+			}},
+			want: "more than one default",
+		},
+		{
+			name: "unused registry",
+			cfg: Config{Registries: []RegistryConfig{
+				{Name: "a", URL: "https://a.example.com"},
+			}},
+			want: "no scopes",
+		},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	_, err := NewRouter(Config{Registries: []RegistryConfig{
+		{Name: "studio", URL: DefaultSupabaseURL + "/", Scopes: []string{"@ourstudio"}},
+	}}, "", "")
+	if err == nil || !strings.Contains(err.Error(), "public registry") {
+		t.Fatalf("expected scope pointing at the public registry to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package gdpmdb
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func (c *Client) SearchPlugins(ctx context.Context, query string, opts SearchOptions) ([]PluginSummary, error) {
+	q := url.Values{}
+	q.Set("order", "name.asc,id.asc")
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		q.Set("offset", strconv.Itoa(opts.Offset))
+	}
+
+	if term := searchTerm(query); term != "" {
+		pattern := "*" + term + "*"
+		filters := []string{"name.ilike." + pattern, "repo.ilike." + pattern}
+
+		owners, err := c.searchUsernames(ctx, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, owner := range owners {
+			if owner.UserID != nil && strings.TrimSpace(*owner.UserID) != "" {
+				filters = append(filters, "user_id.eq."+strings.TrimSpace(*owner.UserID))
+			}
+			if owner.OrgID != nil && strings.TrimSpace(*owner.OrgID) != "" {
+				filters = append(filters, "org_id.eq."+strings.TrimSpace(*owner.OrgID))
+			}
+		}
+		q.Set("or", "("+strings.Join(filters, ",")+")")
+	}
+
+	rows, err := c.getPluginRows(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	ownerNames, err := c.ownerUsernames(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := c.latestVersions(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]PluginSummary, 0, len(rows))
+	for _, row := range rows {
+		owner := ownerNames[ownerKey(row.UserID, row.OrgID)]
+		if owner == "" || row.Name == nil || strings.TrimSpace(*row.Name) == "" {
+			continue
+		}
+		name := strings.TrimSpace(*row.Name)
+		summary := PluginSummary{
+			Name:          "@" + owner + "/" + name,
+			Owner:         owner,
+			Plugin:        name,
+			Repo:          row.Repo,
+			LatestVersion: latest[row.ID],
+		}
+		if row.Path != nil {
+			summary.Path = strings.Trim(strings.TrimSpace(*row.Path), "/")
+		}
+		results = append(results, summary)
+	}
+	return results, nil
+}
+
+func (c *Client) searchUsernames(ctx context.Context, pattern string) ([]usernameRow, error) {
+	q := url.Values{}
+	q.Set("select", "username_normal,user_id,org_id")
+	q.Set("username_normal", "ilike."+pattern)
+	q.Set("limit", "100")
+
+	var rows []usernameRow
+	if err := c.get(ctx, "usernames", q, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (c *Client) ownerUsernames(ctx context.Context, plugins []pluginRow) (map[string]string, error) {
+	var userIDs, orgIDs []string
+	for _, row := range plugins {
+		if row.OrgID != nil && strings.TrimSpace(*row.OrgID) != "" {
+			orgIDs = append(orgIDs, strings.TrimSpace(*row.OrgID))
+		} else if row.UserID != nil && strings.TrimSpace(*row.UserID) != "" {
+			userIDs = append(userIDs, strings.TrimSpace(*row.UserID))
+		}
+	}
+
+	var filters []string
+	if len(userIDs) != 0 {
+		filters = append(filters, "user_id.in.("+strings.Join(userIDs, ",")+")")
+	}
+	if len(orgIDs) != 0 {
+		filters = append(filters, "org_id.in.("+strings.Join(orgIDs, ",")+")")
+	}
+	if len(filters) == 0 {
+		return map[string]string{}, nil
+	}
+
+	q := url.Values{}
+	q.Set("select", "username_normal,user_id,org_id")
+	q.Set("or", "("+strings.Join(filters, ",")+")")
+
+	rows, err := getAllPages[usernameRow](ctx, c, "usernames", q)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(rows))
+	for _, row := range rows {
+		if row.UsernameNormal == nil {
+			continue
+		}
+		names[ownerKey(row.UserID, row.OrgID)] = strings.TrimSpace(*row.UsernameNormal)
+	}
+	return names, nil
+}
+
+func (c *Client) latestVersions(ctx context.Context, plugins []pluginRow) (map[string]string, error) {
+	ids := make([]string, 0, len(plugins))
+	for _, row := range plugins {
+		if id := strings.TrimSpace(row.ID); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return map[string]string{}, nil
+	}
+
+	q := url.Values{}
+	q.Set("plugin_id", "in.("+strings.Join(ids, ",")+")")
+	q.Set("order", "major.desc,minor.desc,patch.desc,created_at.desc")
+
+	rows, err := c.getVersionRows(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	byPlugin := map[string][]versionRow{}
+	for _, row := range rows {
+		if row.PluginID == nil {
+			continue
+		}
+		byPlugin[*row.PluginID] = append(byPlugin[*row.PluginID], row)
+	}
+
+	latest := make(map[string]string, len(byPlugin))
+	for id, versions := range byPlugin {
+		if selected, ok := selectVersion(versions, "", false); ok {
+			latest[id] = rowVersionString(selected)
+		}
+	}
+	return latest, nil
+}
+
+func ownerKey(userID, orgID *string) string {
+	if orgID != nil && strings.TrimSpace(*orgID) != "" {
+		return "org:" + strings.TrimSpace(*orgID)
+	}
+	if userID != nil && strings.TrimSpace(*userID) != "" {
+		return "user:" + strings.TrimSpace(*userID)
+	}
+	return ""
+}
+
+func searchTerm(query string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '*', ',', '(', ')', '"', '\\', '%':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(query))
+}
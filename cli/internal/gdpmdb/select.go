@@ -1,32 +1,46 @@
 package gdpmdb
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/aviorstudio/gdpm/cli/internal/semver"
 )
 
-func selectVersion(rows []versionRow, requested string) (versionRow, bool) {
+func selectVersion(rows []versionRow, requested string, includePrerelease bool) (versionRow, bool) {
 	requested = strings.TrimSpace(requested)
+	var best versionRow
+	var bestSet bool
+
 	if requested != "" {
-		reqVer, ok := semver.Parse(requested)
-		if !ok || len(reqVer.Pre) > 0 {
+		constraint, err := semver.ParseConstraint(requested)
+		if err != nil {
 			return versionRow{}, false
 		}
 		for _, row := range rows {
 			if strings.TrimSpace(row.SHA) == "" {
 				continue
 			}
-			if row.Major == reqVer.Major && row.Minor == reqVer.Minor && row.Patch == reqVer.Patch {
-				return row, true
+			v, ok := rowVersion(row)
+			if !ok {
+				continue
+			}
+			if includePrerelease {
+				if !constraint.MatchesPrerelease(v) {
+					continue
+				}
+			} else if !constraint.Matches(v) {
+				continue
+			}
+			if !bestSet || compareVersion(row, best) > 0 {
+				best = row
+				bestSet = true
 			}
 		}
-		return versionRow{}, false
+		return best, bestSet
 	}
 
-	var best versionRow
-	var bestSet bool
-
 	for _, row := range rows {
 		if strings.TrimSpace(row.SHA) == "" {
 			continue
@@ -34,6 +48,10 @@ func selectVersion(rows []versionRow, requested string) (versionRow, bool) {
 		if row.Major < 0 || row.Minor < 0 || row.Patch < 0 {
 			continue
 		}
+		v, ok := rowVersion(row)
+		if !ok || (len(v.Pre) > 0 && !includePrerelease) {
+			continue
+		}
 		if !bestSet || compareVersion(row, best) > 0 {
 			best = row
 			bestSet = true
@@ -44,7 +62,7 @@ func selectVersion(rows []versionRow, requested string) (versionRow, bool) {
 	}
 
 	for _, row := range rows {
-		if strings.TrimSpace(row.SHA) == "" {
+		if strings.TrimSpace(row.SHA) == "" || rowPrerelease(row) != "" {
 			continue
 		}
 		return row, true
@@ -53,22 +71,50 @@ func selectVersion(rows []versionRow, requested string) (versionRow, bool) {
 	return versionRow{}, false
 }
 
-func compareVersion(a, b versionRow) int {
-	if a.Major != b.Major {
-		return cmpInt(a.Major, b.Major)
+func hasPrerelease(rows []versionRow) bool {
+	for _, row := range rows {
+		if strings.TrimSpace(row.SHA) != "" && rowPrerelease(row) != "" {
+			return true
+		}
 	}
-	if a.Minor != b.Minor {
-		return cmpInt(a.Minor, b.Minor)
+	return false
+}
+
+func rowPrerelease(row versionRow) string {
+	if row.Prerelease == nil {
+		return ""
 	}
-	return cmpInt(a.Patch, b.Patch)
+	return strings.TrimPrefix(strings.TrimSpace(*row.Prerelease), "-")
 }
 
-func cmpInt(a, b int) int {
-	if a < b {
-		return -1
+func rowVersion(row versionRow) (semver.Version, bool) {
+	v := semver.Version{
+		Major: row.Major,
+		Minor: row.Minor,
+		Patch: row.Patch,
 	}
-	if a > b {
-		return 1
+	pre := rowPrerelease(row)
+	if pre == "" {
+		return v, true
 	}
-	return 0
+	return semver.Parse(fmt.Sprintf("%d.%d.%d-%s", row.Major, row.Minor, row.Patch, pre))
+}
+
+func rowVersionString(row versionRow) string {
+	if v, ok := rowVersion(row); ok {
+		return v.String()
+	}
+	return fmt.Sprintf("%d.%d.%d-%s", row.Major, row.Minor, row.Patch, rowPrerelease(row))
+}
+
+func compareVersion(a, b versionRow) int {
+	av, _ := rowVersion(a)
+	bv, _ := rowVersion(b)
+	return semver.Compare(av, bv)
+}
+
+func sortVersionRows(rows []versionRow) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		return compareVersion(rows[i], rows[j]) > 0
+	})
 }
@@ -1,6 +1,9 @@
 package gdpmdb
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestSelectVersionRequested(t *testing.T) {
 	rows := []versionRow{
@@ -8,7 +11,7 @@ func TestSelectVersionRequested(t *testing.T) {
 		{Major: 0, Minor: 2, Patch: 0, SHA: "bbb"},
 	}
 
-	got, ok := selectVersion(rows, "0.2.0")
+	got, ok := selectVersion(rows, "0.2.0", false)
 	if !ok {
 		t.Fatalf("expected ok=true")
 	}
@@ -24,7 +27,7 @@ func TestSelectVersionLatestSemver(t *testing.T) {
 		{Major: 0, Minor: 10, Patch: 0, SHA: "ccc"},
 	}
 
-	got, ok := selectVersion(rows, "")
+	got, ok := selectVersion(rows, "", false)
 	if !ok {
 		t.Fatalf("expected ok=true")
 	}
@@ -32,3 +35,75 @@ func TestSelectVersionLatestSemver(t *testing.T) {
 		t.Fatalf("expected sha=ccc, got %q", got.SHA)
 	}
 }
+
+func TestSelectVersionRange(t *testing.T) {
+	rows := []versionRow{
+		{Major: 1, Minor: 1, Patch: 0, SHA: "aaa"},
+		{Major: 1, Minor: 2, Patch: 0, SHA: "bbb"},
+		{Major: 1, Minor: 2, Patch: 5, SHA: "ccc"},
+		{Major: 1, Minor: 3, Patch: 0, SHA: "ddd"},
+		{Major: 2, Minor: 0, Patch: 0, SHA: "eee"},
+	}
+
+	for _, tc := range []struct {
+		requested string
+		want      string
+	}{
+		{"^1.2", "ddd"},
+		{"~1.2.0", "ccc"},
+		{">=1.0 <1.3", "ccc"},
+		{"1.x", "ddd"},
+		{"2.x", "eee"},
+	} {
+		got, ok := selectVersion(rows, tc.requested, false)
+		if !ok {
+			t.Fatalf("%s: expected ok=true", tc.requested)
+		}
+		if got.SHA != tc.want {
+			t.Fatalf("%s: expected sha=%s, got %q", tc.requested, tc.want, got.SHA)
+		}
+	}
+
+	if _, ok := selectVersion(rows, "^3.0", false); ok {
+		t.Fatalf("expected no match for ^3.0")
+	}
+}
+
+func TestSelectVersionPrerelease(t *testing.T) {
+	pre := func(s string) *string { return &s }
+	rows := []versionRow{
+		{Major: 1, Minor: 4, Patch: 0, SHA: "aaa"},
+		{Major: 2, Minor: 0, Patch: 0, Prerelease: pre("beta.2"), SHA: "bbb"},
+		{Major: 2, Minor: 0, Patch: 0, Prerelease: pre("beta.10"), SHA: "ccc"},
+		{Major: 2, Minor: 0, Patch: 0, Prerelease: pre("alpha.1"), SHA: "ddd"},
+	}
+
+	for _, tc := range []struct {
+		requested  string
+		prerelease bool
+		want       string
+	}{
+		{"", false, "aaa"},
+		{"", true, "ccc"},
+		{"2.0.0-beta.2", false, "bbb"},
+		{">=2.0.0-beta.1", false, "ccc"},
+		{"^1.0", true, "aaa"},
+	} {
+		got, ok := selectVersion(rows, tc.requested, tc.prerelease)
+		if !ok {
+			t.Fatalf("%q (pre=%v): expected ok=true", tc.requested, tc.prerelease)
+		}
+		if got.SHA != tc.want {
+			t.Fatalf("%q (pre=%v): expected sha=%s, got %q", tc.requested, tc.prerelease, tc.want, got.SHA)
+		}
+	}
+
+	sortVersionRows(rows)
+	var order []string
+	for _, row := range rows {
+		order = append(order, rowVersionString(row))
+	}
+	if got := strings.Join(order, " "); got != "2.0.0-beta.10 2.0.0-beta.2 2.0.0-alpha.1 1.4.0" {
+		t.Fatalf("unexpected order: %s", got)
+	}
+}
@@ -16,7 +16,11 @@ func ParseGitHubTreeURL(treeURL string) (string, string, string, error) {
 }
 
 func GitHubTreeURLWithPath(owner, repo, ref, repoPath string) string {
-	base := "https://github.com/" + owner + "/" + repo + "/tree/" + url.PathEscape(ref)
+	return githubTreeURLWithPath("github.com", owner, repo, ref, repoPath)
+}
+
+func githubTreeURLWithPath(host, owner, repo, ref, repoPath string) string {
+	base := "https://" + host + "/" + owner + "/" + repo + "/tree/" + url.PathEscape(ref)
 	repoPath = strings.Trim(strings.TrimSpace(repoPath), "/")
 	if repoPath == "" {
 		return base
@@ -46,15 +50,15 @@ func ParseGitHubTreeURLWithPath(treeURL string) (string, string, string, string,
 		return "", "", "", "", fmt.Errorf("invalid tree url: %w", err)
 	}
 
-	host := strings.ToLower(strings.TrimSpace(u.Hostname()))
-	if !strings.HasSuffix(host, "github.com") {
-		return "", "", "", "", fmt.Errorf("only github.com tree urls are supported (got %s)", host)
+	host := strings.ToLower(strings.TrimSpace(u.Host))
+	if !IsGitHubHost(host) {
+		return "", "", "", "", fmt.Errorf("only github.com tree urls are supported (got %s; list GitHub Enterprise hosts in %s)", host, GitHubHostsEnv)
 	}
 
 	p := strings.Trim(u.EscapedPath(), "/")
 	parts := strings.Split(p, "/")
 	if len(parts) < 4 || parts[0] == "" || parts[1] == "" || parts[2] != "tree" {
-		return "", "", "", "", fmt.Errorf("invalid github tree url (expected github.com/owner/repo/tree/ref): %s", treeURL)
+		return "", "", "", "", fmt.Errorf("invalid github tree url (expected %s/owner/repo/tree/ref): %s", host, treeURL)
 	}
 
 	ref, err := url.PathUnescape(parts[3])
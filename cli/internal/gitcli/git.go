@@ -0,0 +1,152 @@
+package gitcli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aviorstudio/gdpm/cli/internal/semver"
+)
+
+var commitSHARe = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+
+func IsCommitSHA(ref string) bool {
+	return commitSHARe.MatchString(strings.TrimSpace(ref))
+}
+
+func checkArg(kind, value string) error {
+	// Remotes and refs come from gdpm.json; one starting with "-" would be
+	// read by git as an option such as --upload-pack.
+	if strings.HasPrefix(value, "-") {
+		return fmt.Errorf("invalid git %s %q (must not start with -)", kind, value)
+	}
+	return nil
+}
+
+func ResolveRefAndSHA(ctx context.Context, remote, ref string) (string, string, error) {
+	ref = strings.TrimSpace(ref)
+	if err := checkArg("ref", ref); err != nil {
+		return "", "", err
+	}
+	if IsCommitSHA(ref) {
+		return ref, strings.ToLower(ref), nil
+	}
+
+	refs, err := LsRemote(ctx, remote)
+	if err != nil {
+		return "", "", err
+	}
+
+	if ref == "" {
+		var tags []string
+		for name := range refs {
+			if tag, ok := strings.CutPrefix(name, "refs/tags/"); ok {
+				tags = append(tags, tag)
+			}
+		}
+		if best, ok := semver.BestTag(tags); ok {
+			return best, refs["refs/tags/"+best], nil
+		}
+		if sha, ok := refs["HEAD"]; ok {
+			return "HEAD", sha, nil
+		}
+		return "", "", fmt.Errorf("%s has no tags and no HEAD", remote)
+	}
+
+	candidates := []string{"refs/tags/" + ref, "refs/heads/" + ref}
+	if !strings.HasPrefix(ref, "v") {
+		candidates = append(candidates, "refs/tags/v"+ref)
+	}
+	for _, name := range candidates {
+		if sha, ok := refs[name]; ok {
+			return strings.TrimPrefix(strings.TrimPrefix(name, "refs/tags/"), "refs/heads/"), sha, nil
+		}
+	}
+	return "", "", fmt.Errorf("ref not found in %s: %s", remote, ref)
+}
+
+func LsRemote(ctx context.Context, remote string) (map[string]string, error) {
+	if err := checkArg("remote", remote); err != nil {
+		return nil, err
+	}
+	out, err := run(ctx, "", "ls-remote", "--", remote)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := map[string]string{}
+	peeled := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		sha, name, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "\t")
+		if !ok {
+			continue
+		}
+		if base, ok := strings.CutSuffix(name, "^{}"); ok {
+			peeled[base] = sha
+			continue
+		}
+		refs[name] = sha
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for name, sha := range peeled {
+		refs[name] = sha
+	}
+	return refs, nil
+}
+
+func FetchCommit(ctx context.Context, remote, sha, dir string) error {
+	if !IsCommitSHA(sha) {
+		return fmt.Errorf("git sources must be pinned to a full commit SHA (got %q)", sha)
+	}
+	if err := checkArg("remote", remote); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if _, err := run(ctx, dir, "init", "-q"); err != nil {
+		return err
+	}
+
+	// Not every server lets clients fetch an arbitrary commit, so fall back
+	// to fetching all branches and tags before giving up.
+	if _, err := run(ctx, dir, "fetch", "-q", "--depth", "1", "--", remote, sha); err != nil {
+		if _, err2 := run(ctx, dir, "fetch", "-q", "--tags", "--", remote, "+refs/heads/*:refs/remotes/origin/*"); err2 != nil {
+			return err
+		}
+	}
+	if _, err := run(ctx, dir, "checkout", "-q", "--detach", sha, "--"); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(dir, ".git"))
+}
+
+func run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("git sources need the git command: %w", err)
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("git %s failed: %s", args[0], msg)
+	}
+	return out, nil
+}
@@ -0,0 +1,111 @@
+package gitcli
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitCmd(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=gdpm", "GIT_AUTHOR_EMAIL=gdpm@example.com",
+		"GIT_COMMITTER_NAME=gdpm", "GIT_COMMITTER_EMAIL=gdpm@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func newBareRepo(t *testing.T) (string, string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	work := t.TempDir()
+	gitCmd(t, work, "init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(work, "plugin.cfg"), []byte("[plugin]\nversion=\"1\"\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	gitCmd(t, work, "add", ".")
+	gitCmd(t, work, "commit", "-q", "-m", "v1")
+	gitCmd(t, work, "tag", "-a", "v1.0.0", "-m", "v1.0.0")
+	first := gitCmd(t, work, "rev-parse", "HEAD")
+
+	if err := os.WriteFile(filepath.Join(work, "plugin.cfg"), []byte("[plugin]\nversion=\"2\"\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	gitCmd(t, work, "commit", "-q", "-am", "v2")
+	head := gitCmd(t, work, "rev-parse", "HEAD")
+
+	bare := filepath.Join(t.TempDir(), "addon.git")
+	gitCmd(t, work, "clone", "-q", "--bare", work, bare)
+	return "file://" + bare, first, head
+}
+
+func TestResolveRefAndSHA(t *testing.T) {
+	remote, first, head := newBareRepo(t)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		ref     string
+		wantRef string
+		wantSHA string
+	}{
+		{"", "v1.0.0", first},
+		{"1.0.0", "v1.0.0", first},
+		{"main", "main", head},
+		{first, first, first},
+	} {
+		ref, sha, err := ResolveRefAndSHA(ctx, remote, tc.ref)
+		if err != nil {
+			t.Fatalf("ResolveRefAndSHA(%q): %v", tc.ref, err)
+		}
+		if ref != tc.wantRef || sha != tc.wantSHA {
+			t.Fatalf("ResolveRefAndSHA(%q) = %s, %s; want %s, %s", tc.ref, ref, sha, tc.wantRef, tc.wantSHA)
+		}
+	}
+	if _, _, err := ResolveRefAndSHA(ctx, remote, "missing"); err == nil {
+		t.Fatalf("expected missing ref error")
+	}
+	if _, _, err := ResolveRefAndSHA(ctx, "--upload-pack=touch "+filepath.Join(t.TempDir(), "pwned"), ""); err == nil {
+		t.Fatalf("expected a remote starting with - to be rejected")
+	}
+	if _, _, err := ResolveRefAndSHA(ctx, remote, "--heads"); err == nil {
+		t.Fatalf("expected a ref starting with - to be rejected")
+	}
+}
+
+func TestFetchCommit(t *testing.T) {
+	remote, first, _ := newBareRepo(t)
+	dir := filepath.Join(t.TempDir(), "checkout")
+
+	if err := FetchCommit(context.Background(), remote, first, dir); err != nil {
+		t.Fatalf("FetchCommit: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "plugin.cfg"))
+	if err != nil {
+		t.Fatalf("read plugin.cfg: %v", err)
+	}
+	if !strings.Contains(string(b), `version="1"`) {
+		t.Fatalf("expected the pinned commit to be checked out, got %q", b)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); !os.IsNotExist(err) {
+		t.Fatalf("expected .git to be removed, got %v", err)
+	}
+
+	if err := FetchCommit(context.Background(), remote, "main", filepath.Join(t.TempDir(), "x")); err == nil {
+		t.Fatalf("expected a non-SHA ref to be rejected")
+	}
+	if err := FetchCommit(context.Background(), "--upload-pack=true", first, filepath.Join(t.TempDir(), "y")); err == nil {
+		t.Fatalf("expected a remote starting with - to be rejected")
+	}
+}
@@ -0,0 +1,175 @@
+package giteaapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aviorstudio/gdpm/cli/internal/semver"
+)
+
+const apiPath = "/api/v1"
+
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+	userAgent  string
+}
+
+func NewClient(baseURL, token string) *Client {
+	token = strings.TrimSpace(token)
+	if token != "" && !strings.HasPrefix(strings.ToLower(token), "bearer ") && !strings.HasPrefix(strings.ToLower(token), "token ") {
+		token = "token " + token
+	}
+
+	return &Client{
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		baseURL:    strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+		token:      token,
+		userAgent:  "gdpm-cli",
+	}
+}
+
+func (c *Client) ResolveRefAndSHA(ctx context.Context, owner, repo, version string) (string, string, error) {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		ref, err := c.latestVersionRef(ctx, owner, repo)
+		if err != nil {
+			branch, err2 := c.defaultBranch(ctx, owner, repo)
+			if err2 != nil {
+				return "", "", err
+			}
+			ref = branch
+		}
+		sha, err := c.resolveCommitSHA(ctx, owner, repo, ref)
+		if err != nil {
+			return "", "", err
+		}
+		return ref, sha, nil
+	}
+
+	sha, err := c.resolveCommitSHA(ctx, owner, repo, version)
+	if err == nil {
+		return version, sha, nil
+	}
+
+	if !strings.HasPrefix(version, "v") {
+		sha2, err2 := c.resolveCommitSHA(ctx, owner, repo, "v"+version)
+		if err2 == nil {
+			return "v" + version, sha2, nil
+		}
+	}
+	return "", "", err
+}
+
+func (c *Client) DownloadArchive(ctx context.Context, owner, repo, sha, destPath string) error {
+	u := c.baseURL + "/" + path.Join(owner, repo) + "/archive/" + url.PathEscape(sha) + ".zip"
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<10))
+		return fmt.Errorf("gitea archive failed (%d): %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	f, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, resp.Body)
+	return err
+}
+
+func (c *Client) latestVersionRef(ctx context.Context, owner, repo string) (string, error) {
+	var out []struct {
+		Name string `json:"name"`
+	}
+	if err := c.getJSON(ctx, c.repoURL(owner, repo)+"/tags?limit=50", "tags", &out); err != nil {
+		return "", err
+	}
+
+	var tags []string
+	for _, t := range out {
+		if strings.TrimSpace(t.Name) != "" {
+			tags = append(tags, t.Name)
+		}
+	}
+	best, ok := semver.BestTag(tags)
+	if !ok {
+		return "", errors.New("no semver tags found")
+	}
+	return best, nil
+}
+
+func (c *Client) defaultBranch(ctx context.Context, owner, repo string) (string, error) {
+	var out struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := c.getJSON(ctx, c.repoURL(owner, repo), "repo", &out); err != nil {
+		return "", err
+	}
+	out.DefaultBranch = strings.TrimSpace(out.DefaultBranch)
+	if out.DefaultBranch == "" {
+		return "", errors.New("empty default_branch")
+	}
+	return out.DefaultBranch, nil
+}
+
+func (c *Client) resolveCommitSHA(ctx context.Context, owner, repo, ref string) (string, error) {
+	var out []struct {
+		SHA string `json:"sha"`
+	}
+	u := c.repoURL(owner, repo) + "/commits?limit=1&stat=false&sha=" + url.QueryEscape(ref)
+	if err := c.getJSON(ctx, u, "commit lookup", &out); err != nil {
+		return "", err
+	}
+	if len(out) == 0 || strings.TrimSpace(out[0].SHA) == "" {
+		return "", fmt.Errorf("ref not found: %s", ref)
+	}
+	return strings.TrimSpace(out[0].SHA), nil
+}
+
+func (c *Client) repoURL(owner, repo string) string {
+	return c.baseURL + apiPath + "/repos/" + path.Join(owner, repo)
+}
+
+func (c *Client) getJSON(ctx context.Context, u, what string, dst any) error {
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<10))
+		return fmt.Errorf("gitea %s failed (%d): %s", what, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
+
+func (c *Client) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+	return c.httpClient.Do(req)
+}
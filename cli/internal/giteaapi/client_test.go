@@ -0,0 +1,64 @@
+package giteaapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveRefAndSHA_DefaultBranchWithoutTags(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "token secret" {
+			t.Errorf("unexpected authorization header %q", got)
+		}
+		switch r.URL.Path {
+		case "/api/v1/repos/studio/addons/tags":
+			_, _ = w.Write([]byte(`[]`))
+		case "/api/v1/repos/studio/addons":
+			_, _ = w.Write([]byte(`{"default_branch":"main"}`))
+		case "/api/v1/repos/studio/addons/commits":
+			if r.URL.Query().Get("sha") != "main" {
+				_, _ = w.Write([]byte(`[]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[{"sha":"0123456789abcdef0123456789abcdef01234567"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ref, sha, err := NewClient(srv.URL, "secret").ResolveRefAndSHA(context.Background(), "studio", "addons", "")
+	if err != nil {
+		t.Fatalf("ResolveRefAndSHA: %v", err)
+	}
+	if ref != "main" || sha != "0123456789abcdef0123456789abcdef01234567" {
+		t.Fatalf("unexpected result: %s %s", ref, sha)
+	}
+
+	if _, _, err := NewClient(srv.URL, "secret").ResolveRefAndSHA(context.Background(), "studio", "addons", "missing"); err == nil {
+		t.Fatalf("expected an unknown ref to fail")
+	}
+}
+
+func TestDownloadArchive(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/studio/addons/archive/abc.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("zip"))
+	}))
+	defer srv.Close()
+
+	dst := filepath.Join(t.TempDir(), "repo.zip")
+	if err := NewClient(srv.URL, "").DownloadArchive(context.Background(), "studio", "addons", "abc", dst); err != nil {
+		t.Fatalf("DownloadArchive: %v", err)
+	}
+	if b, err := os.ReadFile(dst); err != nil || string(b) != "zip" {
+		t.Fatalf("unexpected archive contents %q (%v)", b, err)
+	}
+}
@@ -16,15 +16,20 @@ import (
 	"github.com/aviorstudio/gdpm/cli/internal/semver"
 )
 
-const apiBaseURL = "https://api.github.com"
+const defaultAPIBaseURL = "https://api.github.com"
 
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
 	token      string
 	userAgent  string
 }
 
 func NewClient(token string) *Client {
+	return NewEnterpriseClient(defaultAPIBaseURL, token)
+}
+
+func NewEnterpriseClient(apiBaseURL, token string) *Client {
 	token = strings.TrimSpace(token)
 	if token != "" && !strings.HasPrefix(strings.ToLower(token), "bearer ") && !strings.HasPrefix(strings.ToLower(token), "token ") {
 		token = "Bearer " + token
@@ -32,6 +37,7 @@ func NewClient(token string) *Client {
 
 	return &Client{
 		httpClient: &http.Client{Timeout: 60 * time.Second},
+		baseURL:    strings.TrimRight(strings.TrimSpace(apiBaseURL), "/"),
 		token:      token,
 		userAgent:  "gdpm-cli",
 	}
@@ -75,7 +81,7 @@ func (c *Client) ResolveRefAndSHA(ctx context.Context, owner, repo, version stri
 }
 
 func (c *Client) DownloadZipball(ctx context.Context, owner, repo, sha, destPath string) error {
-	u := apiBaseURL + "/repos/" + path.Join(owner, repo) + "/zipball/" + url.PathEscape(sha)
+	u := c.baseURL + "/repos/" + path.Join(owner, repo) + "/zipball/" + url.PathEscape(sha)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return err
@@ -122,7 +128,7 @@ func (c *Client) latestVersionRef(ctx context.Context, owner, repo string) (stri
 }
 
 func (c *Client) latestReleaseTag(ctx context.Context, owner, repo string) (string, error) {
-	u := apiBaseURL + "/repos/" + path.Join(owner, repo) + "/releases/latest"
+	u := c.baseURL + "/repos/" + path.Join(owner, repo) + "/releases/latest"
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return "", err
@@ -157,7 +163,7 @@ func (c *Client) latestReleaseTag(ctx context.Context, owner, repo string) (stri
 }
 
 func (c *Client) listTags(ctx context.Context, owner, repo string) ([]string, error) {
-	u := apiBaseURL + "/repos/" + path.Join(owner, repo) + "/tags?per_page=100"
+	u := c.baseURL + "/repos/" + path.Join(owner, repo) + "/tags?per_page=100"
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return nil, err
@@ -193,7 +199,7 @@ func (c *Client) listTags(ctx context.Context, owner, repo string) ([]string, er
 }
 
 func (c *Client) defaultBranch(ctx context.Context, owner, repo string) (string, error) {
-	u := apiBaseURL + "/repos/" + path.Join(owner, repo)
+	u := c.baseURL + "/repos/" + path.Join(owner, repo)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return "", err
@@ -225,7 +231,7 @@ func (c *Client) defaultBranch(ctx context.Context, owner, repo string) (string,
 }
 
 func (c *Client) resolveCommitSHA(ctx context.Context, owner, repo, ref string) (string, error) {
-	u := apiBaseURL + "/repos/" + path.Join(owner, repo) + "/commits/" + ref
+	u := c.baseURL + "/repos/" + path.Join(owner, repo) + "/commits/" + ref
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return "", err
@@ -0,0 +1,31 @@
+package githubapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewEnterpriseClient_UsesAPIBaseURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer corp-token" {
+			t.Errorf("unexpected authorization header %q", got)
+		}
+		if r.URL.Path != "/api/v3/repos/owner/addons/commits/v1.0.0" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"sha":"0123456789abcdef0123456789abcdef01234567"}`))
+	}))
+	defer srv.Close()
+
+	c := NewEnterpriseClient(srv.URL+"/api/v3/", "corp-token")
+	ref, sha, err := c.ResolveRefAndSHA(context.Background(), "owner", "addons", "1.0.0")
+	if err != nil {
+		t.Fatalf("ResolveRefAndSHA: %v", err)
+	}
+	if ref != "v1.0.0" || sha != "0123456789abcdef0123456789abcdef01234567" {
+		t.Fatalf("unexpected result: %s %s", ref, sha)
+	}
+}
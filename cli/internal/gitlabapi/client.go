@@ -0,0 +1,168 @@
+package gitlabapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aviorstudio/gdpm/cli/internal/semver"
+)
+
+const apiPath = "/api/v4"
+
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+	userAgent  string
+}
+
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		baseURL:    strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+		token:      strings.TrimSpace(token),
+		userAgent:  "gdpm-cli",
+	}
+}
+
+func (c *Client) ResolveRefAndSHA(ctx context.Context, project, version string) (string, string, error) {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		ref, err := c.latestVersionRef(ctx, project)
+		if err != nil {
+			branch, err2 := c.defaultBranch(ctx, project)
+			if err2 != nil {
+				return "", "", err
+			}
+			ref = branch
+		}
+		sha, err := c.resolveCommitSHA(ctx, project, ref)
+		if err != nil {
+			return "", "", err
+		}
+		return ref, sha, nil
+	}
+
+	sha, err := c.resolveCommitSHA(ctx, project, version)
+	if err == nil {
+		return version, sha, nil
+	}
+
+	if !strings.HasPrefix(version, "v") {
+		sha2, err2 := c.resolveCommitSHA(ctx, project, "v"+version)
+		if err2 == nil {
+			return "v" + version, sha2, nil
+		}
+	}
+	return "", "", err
+}
+
+func (c *Client) DownloadArchive(ctx context.Context, project, sha, destPath string) error {
+	u := c.projectURL(project) + "/repository/archive.zip?sha=" + url.QueryEscape(sha)
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<10))
+		return fmt.Errorf("gitlab archive failed (%d): %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	f, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, resp.Body)
+	return err
+}
+
+func (c *Client) latestVersionRef(ctx context.Context, project string) (string, error) {
+	var out []struct {
+		Name string `json:"name"`
+	}
+	if err := c.getJSON(ctx, c.projectURL(project)+"/repository/tags?per_page=100", "tags", &out); err != nil {
+		return "", err
+	}
+
+	var tags []string
+	for _, t := range out {
+		if strings.TrimSpace(t.Name) != "" {
+			tags = append(tags, t.Name)
+		}
+	}
+	best, ok := semver.BestTag(tags)
+	if !ok {
+		return "", errors.New("no semver tags found")
+	}
+	return best, nil
+}
+
+func (c *Client) defaultBranch(ctx context.Context, project string) (string, error) {
+	var out struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := c.getJSON(ctx, c.projectURL(project), "project", &out); err != nil {
+		return "", err
+	}
+	out.DefaultBranch = strings.TrimSpace(out.DefaultBranch)
+	if out.DefaultBranch == "" {
+		return "", errors.New("empty default_branch")
+	}
+	return out.DefaultBranch, nil
+}
+
+func (c *Client) resolveCommitSHA(ctx context.Context, project, ref string) (string, error) {
+	var out struct {
+		ID string `json:"id"`
+	}
+	if err := c.getJSON(ctx, c.projectURL(project)+"/repository/commits/"+url.PathEscape(ref), "commit lookup", &out); err != nil {
+		return "", err
+	}
+	out.ID = strings.TrimSpace(out.ID)
+	if out.ID == "" {
+		return "", errors.New("empty sha")
+	}
+	return out.ID, nil
+}
+
+func (c *Client) projectURL(project string) string {
+	return c.baseURL + apiPath + "/projects/" + url.PathEscape(strings.Trim(project, "/"))
+}
+
+func (c *Client) getJSON(ctx context.Context, u, what string, dst any) error {
+	resp, err := c.get(ctx, u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<10))
+		return fmt.Errorf("gitlab %s failed (%d): %s", what, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
+
+func (c *Client) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+	return c.httpClient.Do(req)
+}
@@ -0,0 +1,77 @@
+package gitlabapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveRefAndSHA_LatestTag(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("PRIVATE-TOKEN"); got != "secret" {
+			t.Errorf("unexpected token header %q", got)
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fsub%2Faddons/repository/tags":
+			_, _ = w.Write([]byte(`[{"name":"v1.0.0"},{"name":"v1.2.0"},{"name":"nightly"}]`))
+		case "/api/v4/projects/group%2Fsub%2Faddons/repository/commits/v1.2.0":
+			_, _ = w.Write([]byte(`{"id":"0123456789abcdef0123456789abcdef01234567"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ref, sha, err := NewClient(srv.URL, "secret").ResolveRefAndSHA(context.Background(), "group/sub/addons", "")
+	if err != nil {
+		t.Fatalf("ResolveRefAndSHA: %v", err)
+	}
+	if ref != "v1.2.0" || sha != "0123456789abcdef0123456789abcdef01234567" {
+		t.Fatalf("unexpected result: %s %s", ref, sha)
+	}
+}
+
+func TestResolveRefAndSHA_FallsBackToVPrefixedTag(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() == "/api/v4/projects/group%2Faddons/repository/commits/v2.0.0" {
+			_, _ = w.Write([]byte(`{"id":"abc"}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	ref, sha, err := NewClient(srv.URL, "").ResolveRefAndSHA(context.Background(), "group/addons", "2.0.0")
+	if err != nil {
+		t.Fatalf("ResolveRefAndSHA: %v", err)
+	}
+	if ref != "v2.0.0" || sha != "abc" {
+		t.Fatalf("unexpected result: %s %s", ref, sha)
+	}
+}
+
+func TestDownloadArchive(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Faddons/repository/archive.zip" || r.URL.Query().Get("sha") != "abc" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("zip"))
+	}))
+	defer srv.Close()
+
+	dst := filepath.Join(t.TempDir(), "repo.zip")
+	if err := NewClient(srv.URL, "").DownloadArchive(context.Background(), "group/addons", "abc", dst); err != nil {
+		t.Fatalf("DownloadArchive: %v", err)
+	}
+	if b, err := os.ReadFile(dst); err != nil || string(b) != "zip" {
+		t.Fatalf("unexpected archive contents %q (%v)", b, err)
+	}
+
+	if err := NewClient(srv.URL, "").DownloadArchive(context.Background(), "group/missing", "abc", dst); err == nil {
+		t.Fatalf("expected an error for a missing project")
+	}
+}
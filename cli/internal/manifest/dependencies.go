@@ -0,0 +1,32 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func LoadDependencies(addonDir string) (map[string]string, error) {
+	p := filepath.Join(addonDir, "gdpm.json")
+	if _, err := os.Stat(p); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	m, err := Load(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", p, err)
+	}
+	if len(m.Plugins) == 0 {
+		return nil, nil
+	}
+
+	deps := make(map[string]string, len(m.Plugins))
+	for name, plugin := range m.Plugins {
+		deps[name] = strings.TrimSpace(plugin.Version)
+	}
+	return deps, nil
+}
@@ -0,0 +1,32 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDependencies(t *testing.T) {
+	dir := t.TempDir()
+
+	deps, err := LoadDependencies(dir)
+	if err != nil {
+		t.Fatalf("load without gdpm.json: %v", err)
+	}
+	if deps != nil {
+		t.Fatalf("expected no dependencies, got %v", deps)
+	}
+
+	data := []byte(`{"plugins":{"@user/tween":{"version":" ^1.2.0 "},"@user/util":{}}}`)
+	if err := os.WriteFile(filepath.Join(dir, "gdpm.json"), data, 0o644); err != nil {
+		t.Fatalf("write gdpm.json: %v", err)
+	}
+
+	deps, err = LoadDependencies(dir)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(deps) != 2 || deps["@user/tween"] != "^1.2.0" || deps["@user/util"] != "" {
+		t.Fatalf("unexpected dependencies: %v", deps)
+	}
+}
@@ -0,0 +1,74 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+
+	"github.com/aviorstudio/gdpm/cli/internal/fsutil"
+)
+
+const LockFilename = "gdpm.lock"
+
+type Lock struct {
+	Plugins map[string]LockedPlugin `json:"plugins"`
+}
+
+type LockedPlugin struct {
+	Owner   string `json:"owner"`
+	Repo    string `json:"repo"`
+	Host    string `json:"host,omitempty"`
+	Git     string `json:"git,omitempty"`
+	Subdir  string `json:"subdir,omitempty"`
+	SHA     string `json:"sha"`
+	Version string `json:"version,omitempty"`
+	Zipball string `json:"zipball,omitempty"`
+	Tree    string `json:"tree,omitempty"`
+
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+func NewLock() Lock {
+	return Lock{
+		Plugins: map[string]LockedPlugin{},
+	}
+}
+
+func LoadLock(path string) (Lock, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Lock{}, err
+	}
+
+	var l Lock
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&l); err != nil {
+		return Lock{}, err
+	}
+	if l.Plugins == nil {
+		l.Plugins = map[string]LockedPlugin{}
+	}
+	return l, nil
+}
+
+func SaveLock(path string, l Lock) error {
+	if l.Plugins == nil {
+		l.Plugins = map[string]LockedPlugin{}
+	}
+	out, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	out = append(out, '\n')
+	return fsutil.WriteFileAtomic(path, out, 0o644)
+}
+
+func (p LockedPlugin) SameCommit(other LockedPlugin) bool {
+	return p.Owner == other.Owner &&
+		p.Repo == other.Repo &&
+		p.Host == other.Host &&
+		p.Git == other.Git &&
+		p.Subdir == other.Subdir &&
+		p.SHA == other.SHA
+}
@@ -0,0 +1,49 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSaveLock_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, LockFilename)
+
+	l := NewLock()
+	l.Plugins["@user/plugin"] = LockedPlugin{
+		Owner:   "owner",
+		Repo:    "repo",
+		Subdir:  "addons/plugin",
+		SHA:     "abc123",
+		Version: "1.2.3",
+		Zipball: "sha256:aaa",
+		Tree:    "sha256:bbb",
+
+		Dependencies: map[string]string{"@user/tween": "^1.0"},
+	}
+	if err := SaveLock(p, l); err != nil {
+		t.Fatalf("SaveLock: %v", err)
+	}
+
+	got, err := LoadLock(p)
+	if err != nil {
+		t.Fatalf("LoadLock: %v", err)
+	}
+	if !reflect.DeepEqual(got.Plugins["@user/plugin"], l.Plugins["@user/plugin"]) {
+		t.Fatalf("unexpected entry: %#v", got.Plugins["@user/plugin"])
+	}
+}
+
+func TestLoadLock_RejectsUnknownField(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, LockFilename)
+	if err := os.WriteFile(p, []byte(`{"plugins":{"@user/plugin":{"owner":"o","repo":"r","sha":"s","extra":true}}}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if _, err := LoadLock(p); err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -13,6 +13,8 @@ import (
 
 const LinkFilename = "gdpm.link.json"
 
+const SourceRepo = "repo"
+
 type Manifest struct {
 	Plugins map[string]Plugin `json:"plugins"`
 }
@@ -20,6 +22,8 @@ type Manifest struct {
 type Plugin struct {
 	Repo    string `json:"repo,omitempty"`
 	Version string `json:"version,omitempty"`
+	Source  string `json:"source,omitempty"`
+	Path    string `json:"path,omitempty"`
 	Link    *Link  `json:"link,omitempty"`
 }
 
@@ -88,7 +92,7 @@ func (p *Plugin) UnmarshalJSON(data []byte) error {
 	}
 	for k := range raw {
 		switch k {
-		case "repo", "version":
+		case "repo", "version", "source", "path":
 		case "link":
 			return fmt.Errorf("gdpm.json no longer supports link configuration (move it to %s)", LinkFilename)
 		default:
@@ -99,14 +103,41 @@ func (p *Plugin) UnmarshalJSON(data []byte) error {
 	var tmp struct {
 		Repo    string `json:"repo,omitempty"`
 		Version string `json:"version,omitempty"`
+		Source  string `json:"source,omitempty"`
+		Path    string `json:"path,omitempty"`
 	}
 	if err := json.Unmarshal(data, &tmp); err != nil {
 		return err
 	}
+	switch tmp.Source {
+	case "", SourceRepo:
+	default:
+		return fmt.Errorf("unknown source %q", tmp.Source)
+	}
+	if tmp.Path = strings.TrimSpace(tmp.Path); tmp.Path != "" {
+		if tmp.Repo != "" || tmp.Version != "" || tmp.Source != "" {
+			return fmt.Errorf("path cannot be combined with repo, version or source")
+		}
+		if err := validatePluginPath(tmp.Path); err != nil {
+			return err
+		}
+	}
 
 	*p = Plugin{
 		Repo:    tmp.Repo,
 		Version: tmp.Version,
+		Source:  tmp.Source,
+		Path:    tmp.Path,
+	}
+	return nil
+}
+
+func validatePluginPath(p string) error {
+	if strings.HasPrefix(p, "~") || strings.HasPrefix(p, "/") || strings.HasPrefix(p, "\\") || filepath.IsAbs(p) || (len(p) >= 2 && p[1] == ':') {
+		return fmt.Errorf("path must be relative to the project directory (got %s; use %s for personal overrides)", p, LinkFilename)
+	}
+	if strings.Contains(p, "\\") {
+		return fmt.Errorf("path must use forward slashes (got %s)", p)
 	}
 	return nil
 }
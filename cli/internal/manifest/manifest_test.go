@@ -176,3 +176,55 @@ func TestLoad_MergesLinkManifest(t *testing.T) {
 		t.Fatalf("expected link.enabled=true, got %v", got)
 	}
 }
+
+func TestLoad_Source(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "gdpm.json")
+	if err := os.WriteFile(p, []byte(`{"plugins":{"@user/plugin":{"repo":"https://github.com/user/plugin/tree/abc","source":"repo"}}}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	m, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if m.Plugins["@user/plugin"].Source != SourceRepo {
+		t.Fatalf("expected source to round-trip, got %+v", m.Plugins["@user/plugin"])
+	}
+
+	if err := os.WriteFile(p, []byte(`{"plugins":{"@user/plugin":{"repo":"https://github.com/user/plugin/tree/abc","source":"ftp"}}}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := Load(p); err == nil {
+		t.Fatalf("expected unknown source error")
+	}
+}
+
+func TestLoad_PathSource(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "gdpm.json")
+	if err := os.WriteFile(p, []byte(`{"plugins":{"@studio/ui":{"path":"../libs/ui"}}}`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	m, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := m.Plugins["@studio/ui"].Path; got != "../libs/ui" {
+		t.Fatalf("expected path=../libs/ui, got %q", got)
+	}
+
+	for _, plugin := range []string{
+		`{"path":"/abs/ui"}`,
+		`{"path":"~/dev/ui"}`,
+		`{"path":"C:/dev/ui"}`,
+		`{"path":"libs\\ui"}`,
+		`{"path":"libs/ui","version":"1.0.0"}`,
+	} {
+		if err := os.WriteFile(p, []byte(`{"plugins":{"@studio/ui":`+plugin+`}}`), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if _, err := Load(p); err == nil {
+			t.Fatalf("expected %s to be rejected", plugin)
+		}
+	}
+}
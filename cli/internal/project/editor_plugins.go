@@ -46,6 +46,31 @@ func SetEditorPluginEnabled(projectGodotPath, pluginCfgPath string, enabled bool
 	return true, nil
 }
 
+func EnabledEditorPlugins(projectGodotPath string) ([]string, error) {
+	in, err := os.ReadFile(projectGodotPath)
+	if err != nil {
+		return nil, err
+	}
+	return enabledEditorPluginsText(string(in))
+}
+
+func enabledEditorPluginsText(input string) ([]string, error) {
+	lines := strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n")
+	sectionStart, sectionEnd := findSection(lines, "editor_plugins")
+	if sectionStart == -1 {
+		return nil, nil
+	}
+	for i := sectionStart + 1; i < sectionEnd; i++ {
+		key, value, ok := splitKeyValue(lines[i])
+		if !ok || key != "enabled" {
+			continue
+		}
+		_, values, err := parseGodotStringArray(value)
+		return values, err
+	}
+	return nil, nil
+}
+
 func updateEditorPluginsText(input, pluginCfgPath string, enable bool) (string, bool, error) {
 	lineEnding := "\n"
 	normalized := input
@@ -91,3 +91,19 @@ func TestUpdateEditorPluginsText_NoChangeWhenNotEnabledAndDisabling(t *testing.T
 		t.Fatalf("expected output unchanged")
 	}
 }
+
+func TestEnabledEditorPluginsText(t *testing.T) {
+	in := "config_version=5\r\n\r\n[editor_plugins]\r\nenabled=PackedStringArray(\"res://addons/a/plugin.cfg\", \"res://addons/@user_b/plugin.cfg\")\r\n\r\n[rendering]\r\n"
+	got, err := enabledEditorPluginsText(in)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(got) != 2 || got[0] != "res://addons/a/plugin.cfg" || got[1] != "res://addons/@user_b/plugin.cfg" {
+		t.Fatalf("unexpected enabled plugins: %v", got)
+	}
+
+	got, err = enabledEditorPluginsText("config_version=5\n")
+	if err != nil || len(got) != 0 {
+		t.Fatalf("expected no enabled plugins, got %v (err=%v)", got, err)
+	}
+}
@@ -0,0 +1,324 @@
+package semver
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Constraint struct {
+	raw  string
+	sets [][]comparator
+}
+
+type comparator struct {
+	op  string
+	ver Version
+}
+
+func ParseConstraint(s string) (Constraint, error) {
+	raw := strings.TrimSpace(s)
+	if raw == "" {
+		return Constraint{}, fmt.Errorf("empty version constraint")
+	}
+
+	var sets [][]comparator
+	for _, part := range strings.Split(raw, "||") {
+		set, err := parseComparatorSet(part)
+		if err != nil {
+			return Constraint{}, fmt.Errorf("invalid version constraint %q: %w", raw, err)
+		}
+		sets = append(sets, set)
+	}
+	return Constraint{raw: raw, sets: sets}, nil
+}
+
+func (c Constraint) String() string {
+	return c.raw
+}
+
+func (c Constraint) Exact() (Version, bool) {
+	if len(c.sets) != 1 || len(c.sets[0]) != 1 || c.sets[0][0].op != "=" {
+		return Version{}, false
+	}
+	return c.sets[0][0].ver, true
+}
+
+func (c Constraint) Matches(v Version) bool {
+	for _, set := range c.sets {
+		if setMatches(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Constraint) MatchesPrerelease(v Version) bool {
+	for _, set := range c.sets {
+		matched := true
+		for _, cmp := range set {
+			if !cmp.matches(v) {
+				matched = false
+				break
+			}
+			// "<2.0.0" (and the upper bound of ^1.2) should not admit
+			// 2.0.0-beta.1, even though it sorts below 2.0.0.
+			if cmp.op == "<" && len(cmp.ver.Pre) == 0 && len(v.Pre) > 0 &&
+				cmp.ver.Major == v.Major && cmp.ver.Minor == v.Minor && cmp.ver.Patch == v.Patch {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func IsExact(s string) bool {
+	c, err := ParseConstraint(s)
+	if err != nil {
+		return false
+	}
+	_, ok := c.Exact()
+	return ok
+}
+
+func setMatches(set []comparator, v Version) bool {
+	for _, cmp := range set {
+		if !cmp.matches(v) {
+			return false
+		}
+	}
+	if len(v.Pre) == 0 {
+		return true
+	}
+
+	// A pre-release only satisfies a range that explicitly mentions a
+	// pre-release of the same major.minor.patch.
+	for _, cmp := range set {
+		if len(cmp.ver.Pre) == 0 {
+			continue
+		}
+		if cmp.ver.Major == v.Major && cmp.ver.Minor == v.Minor && cmp.ver.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparator) matches(v Version) bool {
+	d := Compare(v, c.ver)
+	switch c.op {
+	case "=":
+		return d == 0
+	case ">":
+		return d > 0
+	case ">=":
+		return d >= 0
+	case "<":
+		return d < 0
+	case "<=":
+		return d <= 0
+	}
+	return false
+}
+
+func parseComparatorSet(s string) ([]comparator, error) {
+	s = strings.ReplaceAll(s, ",", " ")
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty range")
+	}
+
+	// Allow a space between an operator and its version (">= 1.0").
+	var tokens []string
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		if isOperator(f) && i+1 < len(fields) {
+			f += fields[i+1]
+			i++
+		}
+		tokens = append(tokens, f)
+	}
+
+	var set []comparator
+	for _, tok := range tokens {
+		cmps, err := parseComparator(tok)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, cmps...)
+	}
+	return set, nil
+}
+
+func isOperator(s string) bool {
+	switch s {
+	case "^", "~", "=", ">", ">=", "<", "<=":
+		return true
+	}
+	return false
+}
+
+func parseComparator(tok string) ([]comparator, error) {
+	op := ""
+	for _, candidate := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(tok, candidate) {
+			op = candidate
+			tok = tok[len(candidate):]
+			break
+		}
+	}
+	if op != "" && strings.TrimSpace(tok) == "" {
+		return nil, fmt.Errorf("missing version after %q", op)
+	}
+
+	p, err := parsePartial(tok)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "^":
+		return caretRange(p), nil
+	case "~":
+		return tildeRange(p), nil
+	case "", "=":
+		return xRange(p), nil
+	case ">":
+		if p.minor < 0 {
+			return []comparator{{op: ">=", ver: Version{Major: p.major + 1}}}, nil
+		}
+		if p.patch < 0 {
+			return []comparator{{op: ">=", ver: Version{Major: p.major, Minor: p.minor + 1}}}, nil
+		}
+		return []comparator{{op: ">", ver: p.version()}}, nil
+	case ">=":
+		return []comparator{{op: ">=", ver: p.version()}}, nil
+	case "<":
+		return []comparator{{op: "<", ver: p.version()}}, nil
+	case "<=":
+		if p.minor < 0 {
+			return []comparator{{op: "<", ver: Version{Major: p.major + 1}}}, nil
+		}
+		if p.patch < 0 {
+			return []comparator{{op: "<", ver: Version{Major: p.major, Minor: p.minor + 1}}}, nil
+		}
+		return []comparator{{op: "<=", ver: p.version()}}, nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", op)
+}
+
+type partial struct {
+	major int
+	minor int
+	patch int
+	pre   []identifier
+	any   bool
+}
+
+func (p partial) version() Version {
+	v := Version{Major: p.major, Pre: p.pre}
+	if p.minor > 0 {
+		v.Minor = p.minor
+	}
+	if p.patch > 0 {
+		v.Patch = p.patch
+	}
+	return v
+}
+
+func parsePartial(s string) (partial, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "v")
+	if s == "" || s == "*" || s == "x" || s == "X" {
+		return partial{any: true, minor: -1, patch: -1}, nil
+	}
+
+	core := s
+	pre := ""
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		core = s[:i]
+		pre = s[i+1:]
+	}
+	if i := strings.IndexByte(core, '+'); i >= 0 {
+		core = core[:i]
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return partial{}, fmt.Errorf("invalid version %q", s)
+	}
+
+	nums := []int{-1, -1, -1}
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, ok := parseInt(part)
+		if !ok {
+			return partial{}, fmt.Errorf("invalid version %q", s)
+		}
+		nums[i] = n
+	}
+	if nums[0] < 0 {
+		return partial{any: true, minor: -1, patch: -1}, nil
+	}
+	if pre != "" && nums[2] < 0 {
+		return partial{}, fmt.Errorf("invalid version %q (pre-release needs major.minor.patch)", s)
+	}
+
+	p := partial{major: nums[0], minor: nums[1], patch: nums[2]}
+	if pre != "" {
+		v, ok := Parse(fmt.Sprintf("%d.%d.%d-%s", p.major, p.minor, p.patch, pre))
+		if !ok {
+			return partial{}, fmt.Errorf("invalid version %q", s)
+		}
+		p.pre = v.Pre
+	}
+	return p, nil
+}
+
+func xRange(p partial) []comparator {
+	switch {
+	case p.any:
+		return []comparator{{op: ">=", ver: Version{}}}
+	case p.minor < 0:
+		return []comparator{
+			{op: ">=", ver: Version{Major: p.major}},
+			{op: "<", ver: Version{Major: p.major + 1}},
+		}
+	case p.patch < 0:
+		return []comparator{
+			{op: ">=", ver: Version{Major: p.major, Minor: p.minor}},
+			{op: "<", ver: Version{Major: p.major, Minor: p.minor + 1}},
+		}
+	}
+	return []comparator{{op: "=", ver: p.version()}}
+}
+
+func tildeRange(p partial) []comparator {
+	if p.any || p.minor < 0 {
+		return xRange(p)
+	}
+	return []comparator{
+		{op: ">=", ver: p.version()},
+		{op: "<", ver: Version{Major: p.major, Minor: p.minor + 1}},
+	}
+}
+
+func caretRange(p partial) []comparator {
+	if p.any || p.minor < 0 {
+		return xRange(p)
+	}
+
+	lower := comparator{op: ">=", ver: p.version()}
+	switch {
+	case p.major > 0:
+		return []comparator{lower, {op: "<", ver: Version{Major: p.major + 1}}}
+	case p.minor > 0 || p.patch < 0:
+		return []comparator{lower, {op: "<", ver: Version{Minor: p.minor + 1}}}
+	}
+	return []comparator{lower, {op: "<", ver: Version{Patch: p.patch + 1}}}
+}
@@ -0,0 +1,107 @@
+package semver
+
+import "testing"
+
+func TestConstraintMatches(t *testing.T) {
+	cases := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"1.2.3", "1.2.4", false},
+		{"^1.2", "1.2.0", true},
+		{"^1.2", "1.9.9", true},
+		{"^1.2", "2.0.0", false},
+		{"^1.2", "1.1.9", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~1.2.3", "1.2.2", false},
+		{">=1.0 <2.0", "1.5.0", true},
+		{">=1.0 <2.0", "2.0.0", false},
+		{">= 1.0, < 2.0", "1.0.0", true},
+		{"1.x", "1.9.0", true},
+		{"1.x", "2.0.0", false},
+		{"1.2.x", "1.2.7", true},
+		{"*", "3.1.4", true},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{"<=1.2", "1.2.9", true},
+		{"<=1.2", "1.3.0", false},
+		{"^1.0.0 || ^3.0.0", "3.1.0", true},
+		{"^1.0.0 || ^3.0.0", "2.1.0", false},
+		{"^1.2", "1.3.0-beta.1", false},
+		{">=2.0.0-beta.1", "2.0.0-beta.2", true},
+		{">=2.0.0-beta.1", "2.1.0-beta.2", false},
+	}
+
+	for _, tc := range cases {
+		c, err := ParseConstraint(tc.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q): %v", tc.constraint, err)
+		}
+		v, ok := Parse(tc.version)
+		if !ok {
+			t.Fatalf("Parse(%q) failed", tc.version)
+		}
+		if got := c.Matches(v); got != tc.want {
+			t.Fatalf("%q matches %q = %v, want %v", tc.constraint, tc.version, got, tc.want)
+		}
+	}
+}
+
+func TestConstraintMatchesPrerelease(t *testing.T) {
+	cases := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"^1.2", "1.3.0-beta.1", true},
+		{"^1.2", "2.0.0-beta.1", false},
+		{">=1.0.0", "2.0.0-rc.1", true},
+		{"1.2.3", "1.2.3", true},
+		{"<2.0.0", "2.0.0-rc.1", false},
+		{"<2.0.0", "1.9.0-rc.1", true},
+	}
+
+	for _, tc := range cases {
+		c, err := ParseConstraint(tc.constraint)
+		if err != nil {
+			t.Fatalf("ParseConstraint(%q): %v", tc.constraint, err)
+		}
+		v, ok := Parse(tc.version)
+		if !ok {
+			t.Fatalf("Parse(%q) failed", tc.version)
+		}
+		if got := c.MatchesPrerelease(v); got != tc.want {
+			t.Fatalf("%q matches pre-release %q = %v, want %v", tc.constraint, tc.version, got, tc.want)
+		}
+	}
+}
+
+func TestParseConstraintInvalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"abc",
+		"1.2.3.4",
+		"^",
+		">=1.0 <",
+		"1.2-beta",
+	} {
+		if _, err := ParseConstraint(input); err == nil {
+			t.Fatalf("expected error for %q", input)
+		}
+	}
+}
+
+func TestIsExact(t *testing.T) {
+	if !IsExact("1.2.3") || !IsExact("v1.2.3") || !IsExact("=1.2.3") {
+		t.Fatalf("expected exact versions to be exact")
+	}
+	if IsExact("^1.2.3") || IsExact("1.2") || IsExact(">=1.2.3") {
+		t.Fatalf("expected ranges not to be exact")
+	}
+}
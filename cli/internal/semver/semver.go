@@ -93,6 +93,18 @@ func Parse(s string) (Version, bool) {
 	}, true
 }
 
+func (v Version) String() string {
+	s := strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor) + "." + strconv.Itoa(v.Patch)
+	if len(v.Pre) == 0 {
+		return s
+	}
+	pre := make([]string, len(v.Pre))
+	for i, id := range v.Pre {
+		pre[i] = id.raw
+	}
+	return s + "-" + strings.Join(pre, ".")
+}
+
 func Compare(a, b Version) int {
 	if a.Major != b.Major {
 		return cmpInt(a.Major, b.Major)